@@ -0,0 +1,28 @@
+// Package apierror writes API error responses as JSON.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"tapasrm.dev/cron-ui/requestid"
+)
+
+// Response is the body of every API error
+type Response struct {
+	Error     string `json:"error"`
+	Code      int    `json:"code"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// Write replies to the request with a JSON error body and the given status
+// code. It is the JSON counterpart of http.Error. The request ID set by
+// requestid.Middleware, if any, is included in the body.
+func Write(w http.ResponseWriter, message string, code int) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(Response{Error: message, Code: code, RequestID: h.Get(requestid.Header)})
+}
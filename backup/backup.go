@@ -3,66 +3,116 @@ package backup
 import (
 	"context"
 	"crypto/md5"
+	"database/sql"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	_ "github.com/mattn/go-sqlite3"
 	"tapasrm.dev/cron-ui/storage"
 )
 
 const ChecksumFile = ".last_checksum"
 
+// Result describes the outcome of a backup
+type Result struct {
+	Blob     string `json:"blob"`
+	Checksum string `json:"checksum"`
+	// Skipped is set when the database was unchanged since the last backup
+	Skipped bool `json:"skipped"`
+}
+
 // BackupSQLite uploads the SQLite file if checksum changed.
-func BackupSQLite(ctx context.Context, dbPath, blobName string, store storage.Storage) error {
+func BackupSQLite(ctx context.Context, dbPath, blobName string, store storage.Storage) (Result, error) {
+	result := Result{Blob: blobName}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
 	f, err := os.Open(dbPath)
 	if err != nil {
-		return fmt.Errorf("open db: %w", err)
+		return result, fmt.Errorf("open db: %w", err)
 	}
 	defer f.Close()
 
 	// Compute current file checksum
 	curChecksum, err := fileChecksum(f)
 	if err != nil {
-		return fmt.Errorf("checksum: %w", err)
+		return result, fmt.Errorf("checksum: %w", err)
 	}
+	result.Checksum = curChecksum
 	f.Seek(0, io.SeekStart)
 
-	// Read last uploaded checksum (from local)
-	lastChecksum := readLocalChecksum(filepath.Join(filepath.Dir(dbPath), ChecksumFile))
+	// Read last uploaded checksum. It is kept next to the backup so it
+	// survives the container being recreated; the local file is a fallback
+	// for when that blob can't be read.
+	lastChecksum := readRemoteChecksum(ctx, blobName, store)
+	if lastChecksum == "" {
+		lastChecksum = readLocalChecksum(filepath.Join(filepath.Dir(dbPath), ChecksumFile))
+	}
 
 	if curChecksum == lastChecksum {
 		slog.Debug("Backup skipped (no change detected)", "path", dbPath, "checksum", curChecksum)
-		return nil
+		result.Skipped = true
+		return result, nil
 	}
 
 	// Upload to blob storage
 	slog.Info("Uploading SQLite backup", "path", dbPath, "blob", blobName)
-	_, err = store.UploadFile(ctx, blobName, f)
+	info, err := store.UploadFile(ctx, blobName, f)
 	if err != nil {
-		return fmt.Errorf("upload: %w", err)
+		return result, fmt.Errorf("upload: %w", err)
+	}
+
+	// Check what was stored before trusting it, so a corrupted upload, or a
+	// file that changed while being read, is retried on the next cycle
+	// instead of being skipped as unchanged. The blob is only read back if
+	// the store didn't report its checksum.
+	remoteChecksum := info.ContentMD5
+	if remoteChecksum == "" {
+		remoteChecksum, err = blobChecksum(ctx, blobName, store)
+		if err != nil {
+			return result, fmt.Errorf("verify upload: %w", err)
+		}
+	}
+	if remoteChecksum != curChecksum {
+		return result, fmt.Errorf("verify upload: uploaded blob checksum %s does not match local %s", remoteChecksum, curChecksum)
 	}
 
-	// Save checksum locally
+	// Save checksum next to the backup and locally
+	if err := writeRemoteChecksum(ctx, blobName, curChecksum, store); err != nil {
+		slog.Warn("Failed to store backup checksum, using local file only", "blob", ChecksumBlobName(blobName), "error", err)
+	}
 	writeLocalChecksum(filepath.Join(filepath.Dir(dbPath), ChecksumFile), curChecksum)
 	slog.Info("Backup successful", "path", dbPath, "blob", blobName, "checksum", curChecksum)
-	return nil
+	return result, nil
 }
 
-// ScheduleBackup runs continuous backups every interval.
+// ScheduleBackup runs a backup immediately and then every interval until ctx
+// is cancelled. Cancelling ctx also aborts a backup that is in progress.
 func ScheduleBackup(ctx context.Context, interval time.Duration, dbPath, blobName string, store storage.Storage) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
 	for {
-		err := BackupSQLite(ctx, dbPath, blobName, store)
-		if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := BackupSQLite(ctx, dbPath, blobName, store); err != nil {
+			if ctx.Err() != nil {
+				slog.Info("Backup cancelled", "path", dbPath, "blob", blobName)
+				return
+			}
 			slog.Error("Backup error", "error", err, "path", dbPath, "blob", blobName)
 		}
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(interval):
+		case <-ticker.C:
 		}
 	}
 }
@@ -84,6 +134,14 @@ func RestoreSQLite(ctx context.Context, dbPath, blobName string, store storage.S
 		return fmt.Errorf("create temp: %w", err)
 	}
 
+	// Leave the live DB untouched unless the download checks out
+	keepTemp := false
+	defer func() {
+		if !keepTemp {
+			os.Remove(tempFile)
+		}
+	}()
+
 	h := md5.New()
 	_, err = io.Copy(io.MultiWriter(out, h), rc)
 	out.Close()
@@ -93,17 +151,89 @@ func RestoreSQLite(ctx context.Context, dbPath, blobName string, store storage.S
 
 	checksum := hex.EncodeToString(h.Sum(nil))
 
+	// Compare against the checksum stored alongside the backup, if any
+	if expected := readRemoteChecksum(ctx, blobName, store); expected != "" && expected != checksum {
+		return fmt.Errorf("checksum mismatch: downloaded %s, expected %s", checksum, expected)
+	}
+	if err := checkIntegrity(tempFile); err != nil {
+		return fmt.Errorf("integrity check: %w", err)
+	}
+
+	// Drop the old DB's WAL files so they aren't replayed into the restored one
+	os.Remove(dbPath + "-wal")
+	os.Remove(dbPath + "-shm")
+
 	// Replace existing DB
 	err = os.Rename(tempFile, dbPath)
 	if err != nil {
 		return fmt.Errorf("rename: %w", err)
 	}
+	keepTemp = true
 
 	writeLocalChecksum(filepath.Join(filepath.Dir(dbPath), ChecksumFile), checksum)
 	slog.Info("Restore completed", "path", dbPath, "blob", blobName, "checksum", checksum)
 	return nil
 }
 
+// blobChecksum downloads a blob and returns its MD5
+func blobChecksum(ctx context.Context, blobName string, store storage.Storage) (string, error) {
+	rc, err := store.DownloadFile(ctx, blobName)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checkIntegrity opens the SQLite file at path read-only and runs
+// PRAGMA integrity_check on it.
+func checkIntegrity(path string) error {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("database is corrupt: %s", result)
+	}
+	return nil
+}
+
+// ChecksumBlobName is the sibling blob holding the MD5 of a backup
+func ChecksumBlobName(blobName string) string {
+	return blobName + ".md5"
+}
+
+// readRemoteChecksum returns the checksum stored next to a backup in the
+// store, or "" if there is none.
+func readRemoteChecksum(ctx context.Context, blobName string, store storage.Storage) string {
+	rc, err := store.DownloadFile(ctx, ChecksumBlobName(blobName))
+	if err != nil {
+		return ""
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, 64))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// writeRemoteChecksum stores checksum in the sibling blob of a backup
+func writeRemoteChecksum(ctx context.Context, blobName, checksum string, store storage.Storage) error {
+	_, err := store.UploadFile(ctx, ChecksumBlobName(blobName), strings.NewReader(checksum))
+	return err
+}
+
 func fileChecksum(f *os.File) (string, error) {
 	h := md5.New()
 	if _, err := io.Copy(h, f); err != nil {
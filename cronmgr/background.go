@@ -0,0 +1,47 @@
+package cronmgr
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// periodicTask runs a function on an interval in a background goroutine
+// until stopped. Starting it again replaces the running loop.
+type periodicTask struct {
+	cancel func()
+	wg     sync.WaitGroup
+}
+
+func (p *periodicTask) start(interval time.Duration, fn func(ctx context.Context)) {
+	p.stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(ctx)
+			}
+		}
+	}()
+}
+
+// stop cancels the loop, if running, and waits for it to exit
+func (p *periodicTask) stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	p.wg.Wait()
+	p.cancel = nil
+}
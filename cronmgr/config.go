@@ -0,0 +1,142 @@
+package cronmgr
+
+import (
+	"time"
+
+	"tapasrm.dev/cron-ui/storage"
+)
+
+// Default settings used when a Config field is left empty
+const (
+	DefaultDBPath          = "cron_jobs.db"
+	DefaultBackupBlobName  = "cronos_backups/cron_jobs.db"
+	DefaultDeleteRetention = 7 * 24 * time.Hour
+	DefaultScheduleHorizon = 366 * 24 * time.Hour
+	DefaultShutdownTimeout = 30 * time.Second
+	DefaultMaxRequestBytes = 1 << 20
+	// DefaultBackupStaleFactor flags backups as stale after three missed
+	// intervals
+	DefaultBackupStaleFactor = 3
+	// WAL lets the background sync write while readers are active; with it
+	// NORMAL is durable against application crashes but may lose the last
+	// commits on power loss
+	DefaultSQLiteJournalMode = "WAL"
+	DefaultSQLiteSynchronous = "NORMAL"
+)
+
+// Config holds instance-level settings for a CronManager
+type Config struct {
+	// DBPath is the SQLite file jobs are persisted to
+	DBPath string
+	// SkipJobLoad makes Start begin with no jobs instead of loading those
+	// stored in DBPath. Stored jobs are left in the file, not deleted.
+	SkipJobLoad bool
+	// BackupBlobName is the blob the database is backed up to
+	BackupBlobName string
+	// CustomJobAllowedCommands lists the binaries custom jobs may run. Empty
+	// disables custom jobs; AnyCommand allows any binary.
+	CustomJobAllowedCommands []string
+	// CustomJobAllowShell permits shell metacharacters in custom commands. It
+	// needs CustomJobAllowedCommands to allow any binary.
+	CustomJobAllowShell bool
+	// QueryJobAllowedDSNs lists the data sources query jobs may use, as
+	// written in their dsn. Empty leaves the query job type unregistered.
+	// DBPath is refused even if listed.
+	QueryJobAllowedDSNs []string
+	// QueryJobAllowedDrivers lists the database drivers query jobs may use;
+	// empty allows only sqlite3
+	QueryJobAllowedDrivers []string
+	// MaxJobs caps how many jobs AddJob accepts. Zero means no limit.
+	MaxJobs int
+	// MaxConcurrentRuns caps how many jobs execute at once; further runs
+	// wait for a free slot. Zero means no limit.
+	MaxConcurrentRuns int
+	// DeleteRetention is how long soft-deleted jobs can be restored before
+	// they are purged
+	DeleteRetention time.Duration
+	// ScheduleHorizon is how far ahead a new schedule's first run may be
+	// before it is flagged as a likely mistake
+	ScheduleHorizon time.Duration
+	// RejectDistantSchedules makes AddJob and UpdateJob reject schedules
+	// that never fire or first fire beyond ScheduleHorizon instead of only
+	// logging a warning
+	RejectDistantSchedules bool
+	// RevealToken is the bearer token required to read unmasked job
+	// secrets. Empty disables revealing.
+	RevealToken string
+	// SecretResolver resolves "${secret:key}" references in job config at
+	// run time. Nil makes runs that use references fail.
+	SecretResolver SecretResolver
+	// Timezone is the time zone schedules are evaluated in unless a job sets
+	// its own (default time.Local)
+	Timezone *time.Location
+	// CronWithoutSeconds makes schedules standard five-field expressions
+	// unless a job sets useSeconds; by default they have a leading seconds
+	// field
+	CronWithoutSeconds bool
+	// Use24HourTime writes times in schedule descriptions as 14:00 rather
+	// than 02:00 PM
+	Use24HourTime bool
+	// RequireUniqueNames rejects adding, updating, renaming or restoring a
+	// job to a name another job already has
+	RequireUniqueNames bool
+	// ReadOnly starts the manager as a standby that loads and serves jobs
+	// but never schedules or runs them, until Promote is called
+	ReadOnly bool
+	// SQLiteJournalMode and SQLiteSynchronous set the journal_mode and
+	// synchronous pragmas of the jobs database
+	SQLiteJournalMode string
+	SQLiteSynchronous string
+	// RunLocker, if set, is claimed before each scheduled run so instances
+	// sharing it run every firing once between them
+	RunLocker RunLocker
+	// OutputStore receives run output for jobs with uploadOutputTo set; nil
+	// disables uploads
+	OutputStore storage.Storage
+	// BackupStaleFactor is how many backup intervals may pass without a
+	// successful backup before the backup status carries a warning
+	BackupStaleFactor int
+	// AdminToken is the bearer token required by the admin endpoints; they
+	// are disabled when it is empty
+	AdminToken string
+	// MaxRequestBytes caps the size of JSON request bodies
+	MaxRequestBytes int64
+	// ShutdownTimeout bounds how long Stop waits for running jobs and
+	// background loops before giving up on them
+	ShutdownTimeout time.Duration
+}
+
+// withDefaults returns a copy of c with empty fields filled in
+func (c Config) withDefaults() Config {
+	if c.DBPath == "" {
+		c.DBPath = DefaultDBPath
+	}
+	if c.BackupBlobName == "" {
+		c.BackupBlobName = DefaultBackupBlobName
+	}
+	if c.DeleteRetention <= 0 {
+		c.DeleteRetention = DefaultDeleteRetention
+	}
+	if c.ScheduleHorizon <= 0 {
+		c.ScheduleHorizon = DefaultScheduleHorizon
+	}
+	if c.BackupStaleFactor <= 0 {
+		c.BackupStaleFactor = DefaultBackupStaleFactor
+	}
+	if c.MaxRequestBytes <= 0 {
+		c.MaxRequestBytes = DefaultMaxRequestBytes
+	}
+	if c.Timezone == nil {
+		c.Timezone = time.Local
+	}
+	if c.ShutdownTimeout <= 0 {
+		c.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if c.SQLiteJournalMode == "" {
+		c.SQLiteJournalMode = DefaultSQLiteJournalMode
+	}
+	if c.SQLiteSynchronous == "" {
+		c.SQLiteSynchronous = DefaultSQLiteSynchronous
+	}
+	return c
+}
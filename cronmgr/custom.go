@@ -0,0 +1,259 @@
+package cronmgr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// CustomJobExecutor runs a command line on the host.
+//
+// Config fields:
+//   - command: command line to run (required)
+//   - env: object of extra environment variables for the process
+//   - workdir: absolute directory to run the command in
+//
+// The process gets a minimal base environment (see baseEnvVars) plus env.
+// Env values may hold credentials, so only the variable names are logged.
+type CustomJobExecutor struct {
+	// AllowedCommands lists the binaries a command may invoke. Empty denies
+	// every command, so custom jobs are off until an operator lists some;
+	// AnyCommand allows any binary.
+	AllowedCommands []string
+	// AllowShell permits shell metacharacters in commands, which are run
+	// through sh -c. The shell can run anything, so it is only honoured when
+	// AllowedCommands holds AnyCommand.
+	AllowShell bool
+}
+
+// AnyCommand in AllowedCommands lets custom jobs run any binary
+const AnyCommand = "*"
+
+// shellMetacharacters are rejected in custom commands unless AllowShell is set
+const shellMetacharacters = ";&|$<>`(){}\n\r\\"
+
+// baseEnvVars are copied from the server's environment into every custom
+// command's environment
+var baseEnvVars = []string{"PATH", "HOME", "LANG", "TZ", "TMPDIR"}
+
+// envNamePattern matches valid environment variable names
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedEnvNames can't be set through a job's env, since they choose which
+// code an allowed binary loads or runs at startup. Matched ignoring case.
+var reservedEnvNames = []string{
+	"PATH", "IFS", "ENV", "BASH_ENV", "SHELLOPTS", "BASHOPTS", "PS4", "PROMPT_COMMAND",
+	"GCONV_PATH", "LOCPATH", "NLSPATH", "HOSTALIASES",
+	"PYTHONPATH", "PYTHONSTARTUP", "PYTHONHOME", "PERL5LIB", "PERL5OPT", "PERLLIB",
+	"RUBYLIB", "RUBYOPT", "NODE_OPTIONS", "NODE_PATH", "JAVA_TOOL_OPTIONS", "_JAVA_OPTIONS",
+}
+
+// reservedEnvPrefixes are the dynamic loader variables of Linux and macOS
+var reservedEnvPrefixes = []string{"LD_", "DYLD_"}
+
+// reservedEnvName reports whether name is one a job may not set
+func reservedEnvName(name string) bool {
+	upper := strings.ToUpper(name)
+	if slices.Contains(reservedEnvNames, upper) {
+		return true
+	}
+	for _, prefix := range reservedEnvPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxCustomOutput caps how much command output is kept, from the end
+const maxCustomOutput = 64 * 1024
+
+func (c *CustomJobExecutor) Execute(config map[string]any) error {
+	_, err := c.ExecuteWithOutput(context.Background(), config)
+	return err
+}
+
+func (c *CustomJobExecutor) ExecuteWithOutput(ctx context.Context, config map[string]any) (string, error) {
+	command, _ := config["command"].(string)
+	// Checked again in case the allowed commands changed since the job was
+	// saved
+	if err := c.checkCommand(command); err != nil {
+		return "", err
+	}
+	env, err := customEnv(config)
+	if err != nil {
+		return "", err
+	}
+	workdir, _ := config["workdir"].(string)
+
+	var cmd *exec.Cmd
+	if strings.ContainsAny(command, shellMetacharacters) {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	} else {
+		fields := strings.Fields(command)
+		cmd = exec.CommandContext(ctx, fields[0], fields[1:]...)
+	}
+	cmd.Dir = workdir
+	cmd.Env = make([]string, 0, len(baseEnvVars)+len(env))
+	for _, name := range baseEnvVars {
+		if v, ok := os.LookupEnv(name); ok {
+			cmd.Env = append(cmd.Env, name+"="+v)
+		}
+	}
+	envNames := make([]string, 0, len(env))
+	for name, v := range env {
+		cmd.Env = append(cmd.Env, name+"="+v)
+		envNames = append(envNames, name)
+	}
+	slices.Sort(envNames)
+
+	// The command may embed resolved secrets, so the stored form is logged
+	stored := loggableConfig(ctx, config)
+	logCommand, _ := stored["command"].(string)
+	logWorkdir, _ := stored["workdir"].(string)
+	RunLogger(ctx).Info("Executing custom command", "command", logCommand, "workdir", logWorkdir, "env", envNames)
+	// Output is streamed to the run log as well as collected
+	var buf bytes.Buffer
+	combined := io.MultiWriter(&buf, RunOutput(ctx))
+	cmd.Stdout = combined
+	cmd.Stderr = combined
+	err = cmd.Run()
+	out := buf.Bytes()
+	if len(out) > maxCustomOutput {
+		out = out[len(out)-maxCustomOutput:]
+	}
+	if err != nil {
+		return string(out), fmt.Errorf("command failed: %w", err)
+	}
+	return string(out), nil
+}
+
+// DryRun checks that the command's binary and workdir exist and reports the
+// command line without running it
+func (c *CustomJobExecutor) DryRun(ctx context.Context, config map[string]any) (string, error) {
+	command, _ := config["command"].(string)
+	if err := c.checkCommand(command); err != nil {
+		return "", err
+	}
+	env, err := customEnv(config)
+	if err != nil {
+		return "", err
+	}
+	workdir, _ := config["workdir"].(string)
+
+	useShell := strings.ContainsAny(command, shellMetacharacters)
+	if fields := strings.Fields(command); !useShell && len(fields) > 0 {
+		if _, err := exec.LookPath(fields[0]); err != nil {
+			return "", fmt.Errorf("command not found: %w", err)
+		}
+	}
+	if workdir != "" {
+		if info, err := os.Stat(workdir); err != nil {
+			return "", fmt.Errorf("workdir: %w", err)
+		} else if !info.IsDir() {
+			return "", fmt.Errorf("workdir %s is not a directory", workdir)
+		}
+	}
+
+	envNames := slices.Sorted(maps.Keys(env))
+	out := "Would run: " + command
+	if useShell {
+		out += "\n(through sh -c)"
+	}
+	if workdir != "" {
+		out += "\nin: " + workdir
+	}
+	if len(envNames) > 0 {
+		out += "\nwith env: " + strings.Join(envNames, ", ")
+	}
+	return out, nil
+}
+
+// customEnv returns a custom job's extra environment variables, rejecting
+// invalid and reserved names
+func customEnv(config map[string]any) (map[string]string, error) {
+	raw, ok := config["env"]
+	if !ok {
+		return nil, nil
+	}
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("'env' must be an object of strings")
+	}
+	env := make(map[string]string, len(m))
+	for name, v := range m {
+		if !envNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("'env' key %q is not a valid variable name", name)
+		}
+		if reservedEnvName(name) {
+			return nil, fmt.Errorf("'env' can't set %s, which changes the code commands load or run", name)
+		}
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("'env' value for %s must be a string", name)
+		}
+		env[name] = str
+	}
+	return env, nil
+}
+
+func (c *CustomJobExecutor) Validate(config map[string]any) error {
+	if err := checkRequired(c.ConfigSchema(), config); err != nil {
+		return err
+	}
+	command, ok := config["command"].(string)
+	if !ok {
+		return fmt.Errorf("'command' must be a string")
+	}
+
+	if err := c.checkCommand(command); err != nil {
+		return err
+	}
+	if _, err := customEnv(config); err != nil {
+		return err
+	}
+	if raw, ok := config["workdir"]; ok {
+		if dir, ok := raw.(string); !ok || !filepath.IsAbs(dir) {
+			return fmt.Errorf("'workdir' must be an absolute path")
+		}
+	}
+	return nil
+}
+
+// checkCommand reports whether the executor may run command
+func (c *CustomJobExecutor) checkCommand(command string) error {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return fmt.Errorf("'command' cannot be empty")
+	}
+	if !c.AllowShell && strings.ContainsAny(command, shellMetacharacters) {
+		return fmt.Errorf("'command' contains shell metacharacters, which are not allowed")
+	}
+	if len(c.AllowedCommands) == 0 {
+		return fmt.Errorf("custom job commands are disabled: no commands are allowed")
+	}
+	anyCommand := slices.Contains(c.AllowedCommands, AnyCommand)
+	if !anyCommand && strings.ContainsAny(command, shellMetacharacters) {
+		return fmt.Errorf("'command' contains shell metacharacters, which can't be checked against the allowed list")
+	}
+	if !anyCommand && !slices.Contains(c.AllowedCommands, fields[0]) {
+		return fmt.Errorf("command %q is not in the allowed list", fields[0])
+	}
+	return nil
+}
+
+func (c *CustomJobExecutor) ConfigSchema() []ConfigField {
+	return []ConfigField{
+		{Name: "command", Type: FieldString, Required: true, Description: "Command line to run"},
+		{Name: "env", Type: FieldMap, Sensitive: true, Description: "Extra environment variables; PATH and loader or startup variables such as LD_PRELOAD are refused"},
+		{Name: "workdir", Type: FieldString, Description: "Absolute directory to run in"},
+	}
+}
@@ -0,0 +1,45 @@
+package cronmgr
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCustomJobEnvNames(t *testing.T) {
+	c := &CustomJobExecutor{AllowedCommands: []string{"env"}}
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "REPORT_DIR"},
+		{name: "LANG"},
+		{name: "LD_PRELOAD", wantErr: true},
+		{name: "ld_preload", wantErr: true},
+		{name: "LD_LIBRARY_PATH", wantErr: true},
+		{name: "DYLD_INSERT_LIBRARIES", wantErr: true},
+		{name: "BASH_ENV", wantErr: true},
+		{name: "ENV", wantErr: true},
+		{name: "PATH", wantErr: true},
+		{name: "Path", wantErr: true},
+		{name: "PYTHONPATH", wantErr: true},
+		{name: "NODE_OPTIONS", wantErr: true},
+		{name: "BAD-NAME", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]any{"command": "env", "env": map[string]any{tt.name: "/tmp/evil.so"}}
+			if err := c.Validate(config); (err != nil) != tt.wantErr {
+				t.Errorf("Validate error = %v, want error %t", err, tt.wantErr)
+			}
+			// Stored jobs are checked again when they run
+			out, err := c.ExecuteWithOutput(context.Background(), config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExecuteWithOutput error = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr && strings.Contains(out, tt.name+"=") {
+				t.Errorf("command ran with %s set", tt.name)
+			}
+		})
+	}
+}
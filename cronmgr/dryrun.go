@@ -0,0 +1,110 @@
+package cronmgr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ErrDryRunUnsupported is returned by DryRunJob for job types whose executor
+// doesn't implement DryRunExecutor
+var ErrDryRunUnsupported = errors.New("job type does not support dry runs")
+
+// dryRunTimeout bounds a dry run
+const dryRunTimeout = 30 * time.Second
+
+// DryRunResult is the outcome of a dry run
+type DryRunResult struct {
+	JobID   string `json:"jobId"`
+	Success bool   `json:"success"`
+	// Output is the executor's description of what a real run would do
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Log holds the records logged during the dry run
+	Log string `json:"log,omitempty"`
+	// Warnings list reasons a scheduled run wouldn't execute right now
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// DryRunJob checks a job's config by having its executor describe what a
+// run would do, without doing it. Secret references are resolved to check
+// they exist, but the executor only sees them unresolved, so no secret
+// reaches the result. Nothing is recorded: the job's history, counters and
+// run limits are untouched, and its output isn't uploaded or its success
+// ping sent. Disabled jobs can be dry run, so a new job can be checked
+// before it is enabled.
+func (cm *CronManager) DryRunJob(ctx context.Context, jobID string) (DryRunResult, error) {
+	cm.mu.RLock()
+	job, exists := cm.jobs[jobID]
+	if !exists {
+		cm.mu.RUnlock()
+		return DryRunResult{}, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	jobName := job.Name
+	jobType := job.Type
+	jobEnabled := job.Enabled
+	config := job.Config
+	executor := cm.executors[jobType]
+	cm.mu.RUnlock()
+
+	dr, ok := executor.(DryRunExecutor)
+	if !ok {
+		return DryRunResult{}, fmt.Errorf("%w: %s", ErrDryRunUnsupported, jobType)
+	}
+
+	result := DryRunResult{JobID: jobID}
+	if !jobEnabled {
+		result.Warnings = append(result.Warnings, "job is disabled")
+	}
+	notBefore, notAfter, _ := jobWindow(config)
+	if now := time.Now(); !notBefore.IsZero() && now.Before(notBefore) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("job doesn't run before %s", notBefore.Format(time.RFC3339)))
+	} else if !notAfter.IsZero() && now.After(notAfter) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("job's end date %s has passed", notAfter.Format(time.RFC3339)))
+	}
+	if dep, ok := cm.dependenciesMet(config); !ok {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("dependency %s has not succeeded recently", dep))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dryRunTimeout)
+	defer cancel()
+	l := newRunLog()
+	ctx = withRunLog(ctx, l)
+	logger := RunLogger(ctx)
+	logger.Info("Dry run of job", "job", jobName, "type", jobType, "id", jobID)
+
+	_, err := cm.resolveSecrets(config)
+	if err != nil {
+		err = fmt.Errorf("resolve secrets: %w", err)
+	} else {
+		result.Output, err = dr.DryRun(ctx, config)
+	}
+	if err != nil {
+		logger.Warn("Dry run failed", "job", jobName, "id", jobID, "error", err)
+		result.Error = err.Error()
+	} else {
+		result.Success = true
+	}
+	result.Log = l.String()
+	return result, nil
+}
+
+// HandleDryRunJob dry runs a job and returns what it would do. A dry run
+// that fails is still a 200, with success false and the error.
+func (cm *CronManager) HandleDryRunJob(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["id"]
+
+	result, err := cm.DryRunJob(r.Context(), jobID)
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
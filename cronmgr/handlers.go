@@ -2,17 +2,77 @@ package cronmgr
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"maps"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
-	crondescriptor "github.com/lnquy/cron"
+	"tapasrm.dev/cron-ui/apierror"
 )
 
+// Defaults used when a CORSConfig field is left empty
+var (
+	DefaultCORSAllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	DefaultCORSAllowedHeaders = []string{"Content-Type", "Authorization", "X-Request-ID", "If-Match", "If-None-Match", "If-Modified-Since"}
+)
+
+// DefaultCORSMaxAge is how long browsers may cache a preflight response
+const DefaultCORSMaxAge = time.Hour
+
+// CORSConfig controls which cross-origin callers may use the API
+type CORSConfig struct {
+	// AllowedOrigins lists origins allowed to make credentialed requests.
+	// "*" allows any origin, but without credentials.
+	AllowedOrigins []string
+	// AllowedMethods and AllowedHeaders are sent in preflight responses
+	AllowedMethods []string
+	AllowedHeaders []string
+	// MaxAge is how long a preflight response may be cached
+	MaxAge time.Duration
+}
+
+// withDefaults returns a copy of c with empty fields filled in
+func (c CORSConfig) withDefaults() CORSConfig {
+	if len(c.AllowedMethods) == 0 {
+		c.AllowedMethods = DefaultCORSAllowedMethods
+	}
+	if len(c.AllowedHeaders) == 0 {
+		c.AllowedHeaders = DefaultCORSAllowedHeaders
+	}
+	if c.MaxAge <= 0 {
+		c.MaxAge = DefaultCORSMaxAge
+	}
+	return c
+}
+
+// allowedOrigin returns the value to send in Access-Control-Allow-Origin for
+// a request from origin, or "" if the origin is not allowed.
+func (c CORSConfig) allowedOrigin(origin string) string {
+	if origin == "" {
+		return ""
+	}
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			return "*"
+		}
+		if strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
 // corsResponseWriter wraps http.ResponseWriter to ensure CORS headers are always set
 type corsResponseWriter struct {
 	http.ResponseWriter
-	origin     string
+	cfg        *CORSConfig
+	origin     string // value for Access-Control-Allow-Origin, "" if not allowed
 	headersSet bool
 }
 
@@ -30,134 +90,869 @@ func (w *corsResponseWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streamed responses
+func (w *corsResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func (w *corsResponseWriter) setCORSHeaders() {
-	if w.origin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", w.origin)
-		w.Header().Add("Vary", "Origin")
-	} else {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-	}
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-	w.Header().Set("Access-Control-Allow-Credentials", "true")
-	w.Header().Set("Access-Control-Max-Age", "3600")
 	w.headersSet = true
+	if w.origin == "" {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", w.origin)
+	if w.origin != "*" {
+		// Credentials are never combined with a wildcard origin
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(w.cfg.AllowedMethods, ", "))
+	w.Header().Set("Access-Control-Allow-Headers", strings.Join(w.cfg.AllowedHeaders, ", "))
+	w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, ETag")
+	w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(w.cfg.MaxAge.Seconds())))
 }
 
-// enableCORS is provided so main can wrap the router
-func EnableCORS(next http.Handler) http.Handler {
+// EnableCORS wraps next so that requests from origins allowed by cfg get CORS
+// headers. Other origins get none and are blocked by the browser.
+func EnableCORS(cfg CORSConfig, next http.Handler) http.Handler {
+	cfg = cfg.withDefaults()
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
+		if origin != "" {
+			w.Header().Add("Vary", "Origin")
+		}
+		corsW := &corsResponseWriter{ResponseWriter: w, cfg: &cfg, origin: cfg.allowedOrigin(origin)}
 
 		// Handle preflight OPTIONS request
 		if r.Method == "OPTIONS" {
-			corsW := &corsResponseWriter{ResponseWriter: w, origin: origin}
-			corsW.setCORSHeaders()
-			corsW.WriteHeader(http.StatusOK)
+			corsW.WriteHeader(http.StatusNoContent)
 			return
 		}
 
 		// Wrap response writer to ensure CORS headers are set on all responses
-		corsW := &corsResponseWriter{ResponseWriter: w, origin: origin}
 		next.ServeHTTP(corsW, r)
 	})
 }
 
+// overdueGrace is how far past NextRun an enabled job may be before it is
+// reported overdue, covering the gap while a run is in progress.
+const overdueGrace = time.Minute
+
+// jobResponse is the JSON shape of a job in API responses, adding fields
+// computed at request time that are not persisted. Config shadows the job's
+// own config with secrets masked.
+type jobResponse struct {
+	*Job
+	Config              map[string]any `json:"config"`
+	SecondsUntilNextRun *int64         `json:"secondsUntilNextRun,omitempty"`
+	Overdue             bool           `json:"overdue"`
+}
+
+func (cm *CronManager) newJobResponse(job *Job, now time.Time) jobResponse {
+	resp := jobResponse{Job: job, Config: cm.redactedConfig(job)}
+	if job.NextRun != nil {
+		secs := int64(job.NextRun.Sub(now).Seconds())
+		if secs < 0 {
+			secs = 0
+		}
+		resp.SecondsUntilNextRun = &secs
+		resp.Overdue = jobOverdue(job, now)
+	}
+	return resp
+}
+
+// jobOverdue reports whether an enabled job's next run is more than
+// overdueGrace in the past.
+func jobOverdue(job *Job, now time.Time) bool {
+	return job.Enabled && job.NextRun != nil && now.Sub(*job.NextRun) > overdueGrace
+}
+
+// requestUser returns the principal making the request, taken from the
+// X-User header set by the fronting proxy.
+func requestUser(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get("X-User"))
+}
+
+// writeJobError maps an error from the manager to a response. Known
+// client errors are reported with their message; anything else is logged
+// and returned as a generic 500.
+func writeJobError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrJobNotFound), errors.Is(err, ErrRunNotFound):
+		apierror.Write(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrVersionConflict), errors.Is(err, ErrDuplicateName), errors.Is(err, ErrJobExists),
+		errors.Is(err, ErrReadOnly):
+		apierror.Write(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrJobLimit):
+		apierror.Write(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrUnknownJobType), errors.Is(err, ErrInvalidConfig), errors.Is(err, ErrInvalidSchedule),
+		errors.Is(err, ErrDependencyCycle), errors.Is(err, ErrDryRunUnsupported):
+		apierror.Write(w, err.Error(), http.StatusBadRequest)
+	default:
+		slog.ErrorContext(r.Context(), "Request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+		apierror.Write(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// ndjsonContentType is the Accept value that makes GET /api/jobs stream
+// one job per line
+const ndjsonContentType = "application/x-ndjson"
+
+// ndjsonFlushEvery is how many streamed jobs are written between flushes
+const ndjsonFlushEvery = 100
+
+// streamJobs writes jobs as JSON Lines, flushing periodically so clients can
+// process them as they arrive.
+func (cm *CronManager) streamJobs(w http.ResponseWriter, jobs []*Job, now time.Time) {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	for i, job := range jobs {
+		if err := enc.Encode(cm.newJobResponse(job, now)); err != nil {
+			return
+		}
+		if (i+1)%ndjsonFlushEvery == 0 {
+			rc.Flush()
+		}
+	}
+	if len(jobs) > 0 {
+		rc.Flush()
+	}
+}
+
+// HandleGetJobs lists jobs. Repeated ?tag= parameters restrict the list to
+// jobs carrying all of the given tags, and ?q= to jobs whose name, ID or
+// description contains the given text.
+//
+// The response carries an ETag derived from the manager's revision counter,
+// and a matching If-None-Match gets 304 Not Modified. Clients accepting
+// application/x-ndjson instead get one job per line, streamed.
 func (cm *CronManager) HandleGetJobs(w http.ResponseWriter, r *http.Request) {
-	jobs := cm.GetAllJobs()
+	tags := r.URL.Query()["tag"]
+	query := r.URL.Query().Get("q")
+	// Read the revision before the jobs so a concurrent change can only make
+	// the ETag older than the body, never newer.
+	revision := cm.Revision()
+	jobs := cm.SearchJobs(tags, query)
+	now := time.Now()
+
+	if strings.Contains(r.Header.Get("Accept"), ndjsonContentType) {
+		cm.streamJobs(w, jobs, now)
+		return
+	}
+	resp := make([]jobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		resp = append(resp, cm.newJobResponse(job, now))
+	}
+
+	etag := jobListETag(revision, tags, query, resp)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(jobs)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// jobListETag builds a weak ETag for a job listing from the revision, the
+// tag and search filters, and which jobs are overdue (which changes with
+// time alone).
+func jobListETag(revision uint64, tags []string, query string, jobs []jobResponse) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d|%s|%q|", revision, strings.Join(tags, ","), query)
+	for _, j := range jobs {
+		if j.Overdue {
+			fmt.Fprintf(h, "%s,", j.ID)
+		}
+	}
+	return fmt.Sprintf(`W/"%x"`, h.Sum64())
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleGetJobSummary returns aggregate job counts for dashboards
+func (cm *CronManager) HandleGetJobSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := cm.GetJobSummary(time.Now())
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// HandleGetTags returns the distinct tags in use with their job counts
+func (cm *CronManager) HandleGetTags(w http.ResponseWriter, r *http.Request) {
+	tags := cm.GetTagCounts()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
 }
 
 func (cm *CronManager) HandleGetJob(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	jobID := vars["id"]
 
-	job, err := cm.GetJob(jobID)
+	job, modified, err := cm.getJobModified(jobID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeJobError(w, r, err)
+		return
+	}
+
+	// HTTP dates have one-second resolution
+	modified = modified.Truncate(time.Second)
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	if notModifiedSince(r.Header.Get("If-Modified-Since"), modified) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(job)
+	json.NewEncoder(w).Encode(cm.newJobResponse(job, time.Now()))
+}
+
+// jobRequest is the body of job create and update requests. It also
+// accepts the computed fields of jobResponse so a fetched job can be sent
+// back as is; their values are ignored.
+type jobRequest struct {
+	Job
+	SecondsUntilNextRun json.RawMessage `json:"secondsUntilNextRun"`
+	Overdue             json.RawMessage `json:"overdue"`
+}
+
+// job returns the submitted job with every field the server owns zeroed:
+// status, run history, schedule state, audit fields and deleted or load
+// state. Version is kept since updates check it for conflicts.
+func (req *jobRequest) job() Job {
+	job := req.Job
+	job.ScheduleDesc = ""
+	job.Status = ""
+	job.LastRun = nil
+	job.NextRun = nil
+	job.CreatedBy = ""
+	job.CreatedAt = nil
+	job.UpdatedAt = nil
+	job.LastError = ""
+	job.LastErrorAt = nil
+	job.SuccessCount = 0
+	job.FailureCount = 0
+	job.LastDriftMs = nil
+	job.DeletedAt = nil
+	job.LoadError = ""
+	job.CronEntryIDs = nil
+	return job
+}
+
+// decodeJSON decodes the request body into v, rejecting fields v doesn't
+// have so typos aren't silently dropped. It writes a 413 if the body
+// exceeds Config.MaxRequestBytes and a 400 if it isn't valid, and reports
+// whether decoding succeeded.
+func (cm *CronManager) decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, cm.config.MaxRequestBytes)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apierror.Write(w, fmt.Sprintf("request body must not exceed %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return false
+		}
+		// The decoder has no typed error for unknown fields
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			apierror.Write(w, fmt.Sprintf("unknown field %s", field), http.StatusBadRequest)
+			return false
+		}
+		apierror.Write(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// notModifiedSince reports whether an If-Modified-Since header value is at
+// or after modified. Missing or unparsable values never match.
+func notModifiedSince(header string, modified time.Time) bool {
+	if header == "" {
+		return false
+	}
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
 }
 
 func (cm *CronManager) HandleCreateJob(w http.ResponseWriter, r *http.Request) {
-	var job Job
-	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var req jobRequest
+	if !cm.decodeJSON(w, r, &req) {
 		return
 	}
+	job := req.job()
 
 	if job.ID == "" {
 		job.ID = cm.generateUniqueJobID()
 	}
+	// Versions and audit fields are assigned server-side
+	now := time.Now()
+	job.Version = 0
+	job.CreatedBy = requestUser(r)
+	job.CreatedAt = &now
+	job.UpdatedAt = &now
 
 	if err := cm.AddJob(&job); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJobError(w, r, err)
 		return
 	}
 
+	created := cm.jobSnapshot(&job)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(job)
+	json.NewEncoder(w).Encode(cm.newJobResponse(&created, time.Now()))
 }
 
+// HandleUpdateJob replaces a job. Pass ?merge=true to merge the submitted
+// config onto the existing one; a null value removes that key.
 func (cm *CronManager) HandleUpdateJob(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	jobID := vars["id"]
 
-	var job Job
-	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var req jobRequest
+	if !cm.decodeJSON(w, r, &req) {
 		return
 	}
+	job := req.job()
+
+	// With ?merge=true the submitted config is layered onto the existing one
+	// instead of replacing it. The merged result is validated by UpdateJob.
+	if r.URL.Query().Get("merge") == "true" {
+		merged, err := cm.MergedConfig(jobID, job.Config)
+		if err != nil {
+			writeJobError(w, r, err)
+			return
+		}
+		job.Config = merged
+	}
+	// Clients send back the masked config they read; keep the real secrets
+	cm.keepRedactedSecrets(jobID, job.Type, job.Config)
 
 	job.ID = jobID
 	if err := cm.UpdateJob(jobID, &job); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJobError(w, r, err)
+		return
+	}
+
+	updated := cm.jobSnapshot(&job)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cm.newJobResponse(&updated, time.Now()))
+}
+
+// HandlePatchJob renames a job without rescheduling it, so its run history
+// and next run are kept. The body is {"name": "...", "version": N}; version
+// is optional.
+func (cm *CronManager) HandlePatchJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	var req struct {
+		Name    *string `json:"name"`
+		Version int     `json:"version"`
+	}
+	if !cm.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+		apierror.Write(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	job, err := cm.RenameJob(jobID, *req.Name, req.Version)
+	if err != nil {
+		writeJobError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(job)
+	json.NewEncoder(w).Encode(cm.newJobResponse(job, time.Now()))
 }
 
+// HandleRevealJobSecrets returns a job's config with secrets unmasked. It
+// requires the configured reveal token as a bearer token.
+func (cm *CronManager) HandleRevealJobSecrets(w http.ResponseWriter, r *http.Request) {
+	if !cm.revealAuthorized(r) {
+		apierror.Write(w, "revealing secrets requires a valid token", http.StatusForbidden)
+		return
+	}
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	job, err := cm.GetJob(jobID)
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+	cm.mu.RLock()
+	config := maps.Clone(job.Config)
+	cm.mu.RUnlock()
+	slog.InfoContext(r.Context(), "Revealed job secrets", "id", jobID, "user", requestUser(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]any{"config": config})
+}
+
+// HandleDeleteJob soft-deletes a job so it can be restored later. ?hard=true
+// removes it permanently instead.
 func (cm *CronManager) HandleDeleteJob(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	jobID := vars["id"]
 
-	if err := cm.RemoveJob(jobID); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	remove := cm.DeleteJob
+	if r.URL.Query().Get("hard") == "true" {
+		remove = cm.RemoveJob
+	}
+	if err := remove(jobID); err != nil {
+		writeJobError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// HandleDescribeCron returns a human-readable description of a cron expression
+// HandleRestoreJob brings back a soft-deleted job and returns it
+func (cm *CronManager) HandleRestoreJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	job, err := cm.RestoreJob(jobID)
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cm.newJobResponse(job, time.Now()))
+}
+
+// HandleToggleJob flips a job between enabled and disabled and returns the
+// resulting state
+func (cm *CronManager) HandleToggleJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	state, err := cm.ToggleJob(jobID)
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+// HandleDeleteAllJobs removes every job. It requires the admin token as a
+// bearer token, and ?confirm=true to guard against accidental wipes.
+func (cm *CronManager) HandleDeleteAllJobs(w http.ResponseWriter, r *http.Request) {
+	if !bearerAuthorized(r, cm.config.AdminToken) {
+		apierror.Write(w, "deleting all jobs requires a valid admin token", http.StatusForbidden)
+		return
+	}
+	if r.URL.Query().Get("confirm") != "true" {
+		apierror.Write(w, "deleting all jobs requires ?confirm=true", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cm.RemoveAllJobs()
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Deleted all jobs", "count", deleted)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"deleted": deleted})
+}
+
+// HandleGetJobHistory returns a job's most recent runs, newest first. ?limit=
+// caps the number returned (default 50).
+func (cm *CronManager) HandleGetJobHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	if _, err := cm.GetJob(jobID); err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			apierror.Write(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	runs, err := cm.GetJobRuns(jobID, limit)
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
+// HandlePruneJobHistory deletes a job's runs that started before ?before=
+// (RFC3339), or all of them if it is omitted.
+func (cm *CronManager) HandlePruneJobHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	if _, err := cm.GetJob(jobID); err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	before := time.Now()
+	if v := r.URL.Query().Get("before"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apierror.Write(w, fmt.Sprintf("invalid before: %v", err), http.StatusBadRequest)
+			return
+		}
+		before = t
+	}
+
+	deleted, err := cm.PruneJobRuns(jobID, before)
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"deleted": deleted})
+}
+
+// HandleGetJobDebug returns the job's cron scheduler entry for debugging
+func (cm *CronManager) HandleGetJobDebug(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	state, err := cm.GetSchedulerState(jobID)
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+// HandleCloneJob creates a disabled copy of an existing job
+func (cm *CronManager) HandleCloneJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	job, err := cm.CloneJob(jobID, requestUser(r))
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(cm.newJobResponse(job, time.Now()))
+}
+
+// HandleGetSchedulePresets returns the named schedule presets accepted in
+// a job's schedule field
+func (cm *CronManager) HandleGetSchedulePresets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SchedulePresets(cm.useSeconds(nil)))
+}
+
+// HandleGetJobTypes returns the registered job types and the config fields
+// each accepts
+func (cm *CronManager) HandleGetJobTypes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cm.JobTypes())
+}
+
+// MaintenanceState reports whether scheduling is paused
+type MaintenanceState struct {
+	Maintenance bool `json:"maintenance"`
+}
+
+// HandlePauseAll stops all scheduling until HandleResumeAll is called. It
+// requires the admin token as a bearer token.
+func (cm *CronManager) HandlePauseAll(w http.ResponseWriter, r *http.Request) {
+	if !bearerAuthorized(r, cm.config.AdminToken) {
+		apierror.Write(w, "pausing scheduling requires a valid admin token", http.StatusForbidden)
+		return
+	}
+
+	cm.PauseScheduling()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MaintenanceState{Maintenance: cm.Paused()})
+}
+
+// HandleResumeAll restarts scheduling after HandlePauseAll. It requires the
+// admin token as a bearer token.
+func (cm *CronManager) HandleResumeAll(w http.ResponseWriter, r *http.Request) {
+	if !bearerAuthorized(r, cm.config.AdminToken) {
+		apierror.Write(w, "resuming scheduling requires a valid admin token", http.StatusForbidden)
+		return
+	}
+
+	cm.ResumeScheduling()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MaintenanceState{Maintenance: cm.Paused()})
+}
+
+// ReadOnlyState reports whether the instance is a read-only standby
+type ReadOnlyState struct {
+	ReadOnly bool `json:"readOnly"`
+}
+
+// RequireAdmin wraps a handler so it answers 403 unless the request carries
+// the admin token as a bearer token
+func (cm *CronManager) RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !bearerAuthorized(r, cm.config.AdminToken) {
+			apierror.Write(w, "this operation requires a valid admin token", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// RequireWritable wraps a handler that changes jobs or scheduling so it
+// answers 409 while the manager is read-only
+func (cm *CronManager) RequireWritable(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if cm.ReadOnly() {
+			writeJobError(w, r, fmt.Errorf("%w: promote it to make changes", ErrReadOnly))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// HandlePromote makes a read-only standby the active instance. It requires
+// the admin token as a bearer token.
+func (cm *CronManager) HandlePromote(w http.ResponseWriter, r *http.Request) {
+	if !bearerAuthorized(r, cm.config.AdminToken) {
+		apierror.Write(w, "promotion requires a valid admin token", http.StatusForbidden)
+		return
+	}
+
+	cm.Promote()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReadOnlyState{ReadOnly: cm.ReadOnly()})
+}
+
+// HandleBackupNow saves jobs and backs the database up immediately. It
+// requires the admin token as a bearer token.
+func (cm *CronManager) HandleBackupNow(w http.ResponseWriter, r *http.Request) {
+	if !bearerAuthorized(r, cm.config.AdminToken) {
+		apierror.Write(w, "backups require a valid admin token", http.StatusForbidden)
+		return
+	}
+
+	result, err := cm.BackupNow(r.Context())
+	if errors.Is(err, ErrBackupDisabled) {
+		apierror.Write(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "On-demand backup failed", "error", err)
+		apierror.Write(w, fmt.Sprintf("backup failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "On-demand backup", "blob", result.Blob, "checksum", result.Checksum, "skipped", result.Skipped, "user", requestUser(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// HandleListBackups lists the available database backups, newest first. It
+// requires the admin token as a bearer token.
+func (cm *CronManager) HandleListBackups(w http.ResponseWriter, r *http.Request) {
+	if !bearerAuthorized(r, cm.config.AdminToken) {
+		apierror.Write(w, "backups require a valid admin token", http.StatusForbidden)
+		return
+	}
+
+	backups, err := cm.ListBackups(r.Context())
+	if errors.Is(err, ErrBackupDisabled) {
+		apierror.Write(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		apierror.Write(w, fmt.Sprintf("failed to list backups: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backups)
+}
+
+// HandleBackupStatus reports when backups last ran and succeeded and any
+// pending retry. It requires the admin token as a bearer token.
+func (cm *CronManager) HandleBackupStatus(w http.ResponseWriter, r *http.Request) {
+	if !bearerAuthorized(r, cm.config.AdminToken) {
+		apierror.Write(w, "backups require a valid admin token", http.StatusForbidden)
+		return
+	}
+
+	status, err := cm.BackupStatus()
+	if errors.Is(err, ErrBackupDisabled) {
+		apierror.Write(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// HandleReconcile re-registers enabled jobs missing from the scheduler and
+// reports what it found. It requires the admin token as a bearer token.
+func (cm *CronManager) HandleReconcile(w http.ResponseWriter, r *http.Request) {
+	if !bearerAuthorized(r, cm.config.AdminToken) {
+		apierror.Write(w, "reconciling requires a valid admin token", http.StatusForbidden)
+		return
+	}
+
+	result := cm.Reconcile()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// HandleDescribeCron returns a human-readable description of a cron
+// expression. An optional hasSeconds says whether it has a leading seconds
+// field; it defaults to the instance setting, as for a job's useSeconds.
+// An optional use24HourTime chooses 14:00 or 02:00 PM for times of day,
+// defaulting to the instance setting.
 func (cm *CronManager) HandleDescribeCron(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Schedule string `json:"schedule"`
+		Schedule      string `json:"schedule"`
+		HasSeconds    *bool  `json:"hasSeconds"`
+		Use24HourTime *bool  `json:"use24HourTime"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !cm.decodeJSON(w, r, &req) {
 		return
 	}
 
-	description, err := cm.cronDescriptor.ToDescription(req.Schedule, crondescriptor.Locale_en)
+	seconds := cm.describeSeconds(req.HasSeconds)
+	hour24 := cm.describe24Hour(req.Use24HourTime)
+	description, err := cm.describeExpr(req.Schedule, seconds, hour24)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid cron expression: %v", err), http.StatusBadRequest)
+		apierror.Write(w, fmt.Sprintf("Invalid cron expression: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	response := map[string]string{
-		"schedule":    req.Schedule,
-		"description": description,
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cm.describeSchedule(req.Schedule, description, seconds, hour24))
+}
+
+// describeSeconds resolves a describe request's hasSeconds flag, falling
+// back to the instance default
+func (cm *CronManager) describeSeconds(hasSeconds *bool) bool {
+	if hasSeconds != nil {
+		return *hasSeconds
+	}
+	return cm.useSeconds(nil)
+}
+
+// describe24Hour resolves a describe request's use24HourTime flag, falling
+// back to the instance default
+func (cm *CronManager) describe24Hour(use24HourTime *bool) bool {
+	if use24HourTime != nil {
+		return *use24HourTime
+	}
+	return cm.config.Use24HourTime
+}
+
+// maxDescribeBatch caps how many expressions one batch describe request
+// may contain
+const maxDescribeBatch = 100
+
+// describeResult describes a cron expression. Warning flags schedules that
+// never fire or first fire beyond the configured horizon.
+type describeResult struct {
+	Schedule      string     `json:"schedule"`
+	HasSeconds    bool       `json:"hasSeconds"`
+	Use24HourTime bool       `json:"use24HourTime"`
+	Description   string     `json:"description,omitempty"`
+	NextRun       *time.Time `json:"nextRun,omitempty"`
+	Warning       string     `json:"warning,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// describeSchedule builds the describe response for a schedule that has
+// already been described, adding its first run and any horizon warning.
+func (cm *CronManager) describeSchedule(schedule, description string, seconds, hour24 bool) describeResult {
+	result := describeResult{Schedule: schedule, HasSeconds: seconds, Use24HourTime: hour24, Description: description}
+	now := time.Now()
+	next, err := cm.firstRun(schedule, map[string]any{optUseSeconds: seconds}, now)
+	if err != nil {
+		result.Warning = fmt.Sprintf("Schedule is not accepted by the scheduler: %v", err)
+		return result
+	}
+	if !next.IsZero() {
+		result.NextRun = &next
+	}
+	if err := horizonError(schedule, next, now, cm.config.ScheduleHorizon); err != nil {
+		result.Warning = err.Error()
+	}
+	return result
+}
+
+// HandleDescribeCronBatch describes several cron expressions at once,
+// returning results in request order with per-item errors. hasSeconds and
+// use24HourTime apply to every expression.
+func (cm *CronManager) HandleDescribeCronBatch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Schedules     []string `json:"schedules"`
+		HasSeconds    *bool    `json:"hasSeconds"`
+		Use24HourTime *bool    `json:"use24HourTime"`
+	}
+
+	if !cm.decodeJSON(w, r, &req) {
+		return
+	}
+	if len(req.Schedules) > maxDescribeBatch {
+		apierror.Write(w, fmt.Sprintf("at most %d schedules can be described at once", maxDescribeBatch), http.StatusBadRequest)
+		return
+	}
+
+	seconds := cm.describeSeconds(req.HasSeconds)
+	hour24 := cm.describe24Hour(req.Use24HourTime)
+	results := make([]describeResult, 0, len(req.Schedules))
+	for _, schedule := range req.Schedules {
+		description, err := cm.describeExpr(schedule, seconds, hour24)
+		if err != nil {
+			results = append(results, describeResult{Schedule: schedule, HasSeconds: seconds, Use24HourTime: hour24, Error: fmt.Sprintf("Invalid cron expression: %v", err)})
+			continue
+		}
+		results = append(results, cm.describeSchedule(schedule, description, seconds, hour24))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(results)
 }
@@ -0,0 +1,228 @@
+package cronmgr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestHandleUpdateJobErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		body string
+		want int
+	}{
+		{
+			name: "missing job",
+			id:   "missing",
+			body: `{"name":"missing","type":"custom","schedule":["0 0 * * * *"],"enabled":true,"config":{"command":"ls"},"version":1}`,
+			want: http.StatusNotFound,
+		},
+		{
+			name: "command not allowed",
+			id:   "job",
+			body: `{"name":"job","type":"custom","schedule":["0 0 * * * *"],"enabled":true,"config":{"command":"rm"},"version":1}`,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "missing command",
+			id:   "job",
+			body: `{"name":"job","type":"custom","schedule":["0 0 * * * *"],"enabled":true,"config":{},"version":1}`,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "invalid schedule",
+			id:   "job",
+			body: `{"name":"job","type":"custom","schedule":["not a schedule"],"enabled":true,"config":{"command":"ls"},"version":1}`,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "unknown job type",
+			id:   "job",
+			body: `{"name":"job","type":"nope","schedule":["0 0 * * * *"],"enabled":true,"config":{},"version":1}`,
+			want: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := newTestManager(t, filepath.Join(t.TempDir(), "jobs.db"))
+			if err := cm.AddJob(testJob("job")); err != nil {
+				t.Fatalf("AddJob: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPut, "/api/jobs/"+tt.id, strings.NewReader(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			rec := httptest.NewRecorder()
+			cm.HandleUpdateJob(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body %s", rec.Code, tt.want, rec.Body)
+			}
+			// A rejected update leaves the existing job as it was
+			job, err := cm.GetJob("job")
+			if err != nil {
+				t.Fatalf("GetJob: %v", err)
+			}
+			if job.Version != 1 || job.Config["command"] != "ls" {
+				t.Errorf("job changed to version %d, command %v", job.Version, job.Config["command"])
+			}
+		})
+	}
+}
+
+func TestEnableCORS(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		method  string
+		origin  string
+		// wantOrigin is the expected Access-Control-Allow-Origin, "" for none
+		wantOrigin      string
+		wantCredentials bool
+		wantNext        bool
+	}{
+		{name: "preflight from allowed origin", allowed: []string{"https://app.example.com"}, method: http.MethodOptions, origin: "https://app.example.com", wantOrigin: "https://app.example.com", wantCredentials: true},
+		{name: "preflight from disallowed origin", allowed: []string{"https://app.example.com"}, method: http.MethodOptions, origin: "https://evil.example.com"},
+		{name: "preflight matches case and trailing slash", allowed: []string{"https://App.example.com/"}, method: http.MethodOptions, origin: "https://app.example.com", wantOrigin: "https://app.example.com", wantCredentials: true},
+		{name: "preflight with wildcard", allowed: []string{"*"}, method: http.MethodOptions, origin: "https://any.example.com", wantOrigin: "*"},
+		{name: "preflight with no origins allowed", method: http.MethodOptions, origin: "https://app.example.com"},
+		{name: "request from allowed origin", allowed: []string{"https://app.example.com"}, method: http.MethodGet, origin: "https://app.example.com", wantOrigin: "https://app.example.com", wantCredentials: true, wantNext: true},
+		{name: "request from disallowed origin", allowed: []string{"https://app.example.com"}, method: http.MethodGet, origin: "https://evil.example.com", wantNext: true},
+		{name: "same-origin request", allowed: []string{"https://app.example.com"}, method: http.MethodGet, wantNext: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := EnableCORS(CORSConfig{AllowedOrigins: tt.allowed}, next)
+
+			req := httptest.NewRequest(tt.method, "/api/jobs", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+				req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if called != tt.wantNext {
+				t.Errorf("next called = %t, want %t", called, tt.wantNext)
+			}
+			if tt.method == http.MethodOptions && rec.Code != http.StatusNoContent {
+				t.Errorf("preflight status = %d, want %d", rec.Code, http.StatusNoContent)
+			}
+			h := rec.Header()
+			if got := h.Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := h.Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials set = %t, want %t", got, tt.wantCredentials)
+			}
+			if tt.wantOrigin == "" {
+				if got := h.Get("Access-Control-Allow-Methods"); got != "" {
+					t.Errorf("Access-Control-Allow-Methods = %q for a disallowed origin", got)
+				}
+			} else {
+				if got, want := h.Get("Access-Control-Allow-Methods"), strings.Join(DefaultCORSAllowedMethods, ", "); got != want {
+					t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, want)
+				}
+				if got, want := h.Get("Access-Control-Max-Age"), "3600"; got != want {
+					t.Errorf("Access-Control-Max-Age = %q, want %q", got, want)
+				}
+			}
+			if got, want := h.Get("Vary") == "Origin", tt.origin != ""; got != want {
+				t.Errorf("Vary: Origin set = %t, want %t", got, want)
+			}
+		})
+	}
+}
+
+func TestSchedulingAdminHandlersRequireToken(t *testing.T) {
+	handlers := map[string]struct {
+		method, target string
+		handler        func(*CronManager) http.HandlerFunc
+	}{
+		"reconcile":  {http.MethodPost, "/api/admin/reconcile", func(cm *CronManager) http.HandlerFunc { return cm.HandleReconcile }},
+		"pause-all":  {http.MethodPost, "/api/admin/pause-all", func(cm *CronManager) http.HandlerFunc { return cm.HandlePauseAll }},
+		"resume-all": {http.MethodPost, "/api/admin/resume-all", func(cm *CronManager) http.HandlerFunc { return cm.HandleResumeAll }},
+		"delete-all": {http.MethodDelete, "/api/jobs?confirm=true", func(cm *CronManager) http.HandlerFunc { return cm.HandleDeleteAllJobs }},
+	}
+	tokens := []struct {
+		name       string
+		adminToken string
+		header     string
+		want       int
+	}{
+		{name: "no admin token configured", header: "Bearer ", want: http.StatusForbidden},
+		{name: "missing token", adminToken: "secret", want: http.StatusForbidden},
+		{name: "wrong token", adminToken: "secret", header: "Bearer wrong", want: http.StatusForbidden},
+		{name: "valid token", adminToken: "secret", header: "Bearer secret", want: http.StatusOK},
+	}
+	for path, h := range handlers {
+		for _, tt := range tokens {
+			t.Run(path+"/"+tt.name, func(t *testing.T) {
+				cm := NewCronManager(Config{
+					DBPath:                   filepath.Join(t.TempDir(), "jobs.db"),
+					AdminToken:               tt.adminToken,
+					CustomJobAllowedCommands: []string{"ls"},
+				})
+				t.Cleanup(func() { cm.closeDB() })
+				if err := cm.AddJob(testJob("job")); err != nil {
+					t.Fatalf("AddJob: %v", err)
+				}
+
+				req := httptest.NewRequest(h.method, h.target, nil)
+				if tt.header != "" {
+					req.Header.Set("Authorization", tt.header)
+				}
+				rec := httptest.NewRecorder()
+				h.handler(cm)(rec, req)
+
+				if rec.Code != tt.want {
+					t.Errorf("status = %d, want %d; body %s", rec.Code, tt.want, rec.Body)
+				}
+				if path == "pause-all" && cm.Paused() != (tt.want == http.StatusOK) {
+					t.Errorf("paused = %t after status %d", cm.Paused(), rec.Code)
+				}
+				if _, err := cm.GetJob("job"); path == "delete-all" && (err == nil) == (tt.want == http.StatusOK) {
+					t.Errorf("GetJob after status %d: %v", rec.Code, err)
+				}
+			})
+		}
+	}
+}
+
+func TestHandleCreateJobExistingID(t *testing.T) {
+	cm := newTestManager(t, filepath.Join(t.TempDir(), "jobs.db"))
+	if err := cm.AddJob(testJob("job")); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	before, _ := cm.GetJob("job")
+	entries := slices.Clone(before.CronEntryIDs)
+
+	body := `{"id":"job","name":"other","type":"custom","schedule":["0 30 * * * *"],"enabled":true,"config":{"command":"ls"}}`
+	rec := httptest.NewRecorder()
+	cm.HandleCreateJob(rec, httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(body)))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d; body %s", rec.Code, http.StatusConflict, rec.Body)
+	}
+
+	after, err := cm.GetJob("job")
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if after.Name != "job" || after.Version != before.Version || !slices.Equal(after.Schedule, before.Schedule) {
+		t.Errorf("job replaced: name %q, version %d, schedule %v", after.Name, after.Version, after.Schedule)
+	}
+	if !slices.Equal(after.CronEntryIDs, entries) || len(cm.cron.Entries()) != len(entries) {
+		t.Errorf("cron entries = %v with %d scheduled, want %v", after.CronEntryIDs, len(cm.cron.Entries()), entries)
+	}
+}
@@ -0,0 +1,230 @@
+package cronmgr
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"tapasrm.dev/cron-ui/storage"
+)
+
+// jobRunsSchema stores one row per job execution
+const jobRunsSchema = `CREATE TABLE IF NOT EXISTS job_runs (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        job_id TEXT NOT NULL,
+        started_at INTEGER NOT NULL,
+        finished_at INTEGER NOT NULL,
+        success INTEGER NOT NULL,
+        error TEXT
+    );
+    CREATE INDEX IF NOT EXISTS idx_job_runs_job_started ON job_runs(job_id, started_at);`
+
+// addedRunColumns lists columns introduced after the original job_runs schema
+var addedRunColumns = []column{
+	{"output", "TEXT"},
+	{"output_url", "TEXT"},
+	{"log", "TEXT"},
+}
+
+// maxRunOutput caps how much executor output is kept per run
+const maxRunOutput = 4096
+
+// JobRun is a single recorded execution of a job
+type JobRun struct {
+	ID         int64     `json:"id"`
+	JobID      string    `json:"jobId"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	Output     string    `json:"output,omitempty"`
+	// OutputURL is where the run's full output was uploaded, for jobs with
+	// uploadOutputTo set
+	OutputURL string `json:"outputUrl,omitempty"`
+	// Log is the run's log, served separately by HandleGetJobLogs
+	Log string `json:"-"`
+}
+
+// recordRun appends a run to the history table and returns its ID. It is a
+// no-op if no database is open.
+func (cm *CronManager) recordRun(run JobRun) (int64, error) {
+	db := cm.currentDB()
+	if db == nil {
+		return 0, nil
+	}
+	output := run.Output
+	if len(output) > maxRunOutput {
+		output = output[:maxRunOutput]
+	}
+	res, err := db.Exec(`INSERT INTO job_runs(job_id,started_at,finished_at,success,error,output,output_url,log) VALUES(?,?,?,?,?,?,?,?)`,
+		run.JobID, run.StartedAt.UnixMilli(), run.FinishedAt.UnixMilli(), boolToInt(run.Success), run.Error, output, run.OutputURL, run.Log)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// outputUploadTimeout bounds each upload of a run's output
+const outputUploadTimeout = time.Minute
+
+// uploadRunOutput stores a run's output as
+// <folder>/<job id>/<start time>.log in the output store and returns its URL
+func (cm *CronManager) uploadRunOutput(folder, jobID string, startedAt time.Time, output string) (string, error) {
+	if cm.config.OutputStore == nil {
+		return "", fmt.Errorf("no output storage is configured")
+	}
+	dir, err := storage.SanitizeFileName(jobID)
+	if err != nil {
+		return "", err
+	}
+	name := path.Join(folder, dir, startedAt.UTC().Format("20060102T150405.000Z")+".log")
+
+	ctx, cancel := context.WithTimeout(cm.runCtx, outputUploadTimeout)
+	defer cancel()
+	info, err := cm.config.OutputStore.UploadFile(ctx, name, strings.NewReader(output))
+	if err != nil {
+		return "", fmt.Errorf("upload %s: %w", name, err)
+	}
+	return info.URL, nil
+}
+
+// GetJobRuns returns up to limit of a job's most recent runs, newest first.
+func (cm *CronManager) GetJobRuns(jobID string, limit int) ([]JobRun, error) {
+	db := cm.currentDB()
+	if db == nil {
+		return nil, fmt.Errorf("database not open")
+	}
+
+	rows, err := db.Query(`SELECT id,job_id,started_at,finished_at,success,error,output,output_url FROM job_runs
+        WHERE job_id = ? ORDER BY started_at DESC, id DESC LIMIT ?`, jobID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []JobRun{}
+	for rows.Next() {
+		var run JobRun
+		var startedAt, finishedAt int64
+		var success int
+		var errText, output, outputURL *string
+		if err := rows.Scan(&run.ID, &run.JobID, &startedAt, &finishedAt, &success, &errText, &output, &outputURL); err != nil {
+			return nil, err
+		}
+		run.StartedAt = time.UnixMilli(startedAt)
+		run.FinishedAt = time.UnixMilli(finishedAt)
+		run.Success = intToBool(success)
+		if errText != nil {
+			run.Error = *errText
+		}
+		if output != nil {
+			run.Output = *output
+		}
+		if outputURL != nil {
+			run.OutputURL = *outputURL
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// lastSuccessAt returns when the job's most recent successful run finished,
+// or the zero time if it has none.
+func (cm *CronManager) lastSuccessAt(jobID string) (time.Time, error) {
+	db := cm.currentDB()
+	if db == nil {
+		return time.Time{}, fmt.Errorf("database not open")
+	}
+
+	var finished sql.NullInt64
+	err := db.QueryRow(`SELECT MAX(finished_at) FROM job_runs WHERE job_id = ? AND success = 1`, jobID).Scan(&finished)
+	if err != nil || !finished.Valid {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(finished.Int64), nil
+}
+
+// countRunsSince returns how many runs of any job started at or after since.
+// It returns 0 if no database is open.
+func (cm *CronManager) countRunsSince(since time.Time) (int64, error) {
+	db := cm.currentDB()
+	if db == nil {
+		return 0, nil
+	}
+
+	var count int64
+	err := db.QueryRow(`SELECT COUNT(*) FROM job_runs WHERE started_at >= ?`, since.UnixMilli()).Scan(&count)
+	return count, err
+}
+
+// PruneJobRuns deletes a job's runs that started before the cutoff and
+// returns how many were removed. An empty jobID prunes runs of every job.
+func (cm *CronManager) PruneJobRuns(jobID string, before time.Time) (int64, error) {
+	db := cm.currentDB()
+	if db == nil {
+		return 0, fmt.Errorf("database not open")
+	}
+
+	query := `DELETE FROM job_runs WHERE started_at < ?`
+	args := []any{before.UnixMilli()}
+	if jobID != "" {
+		query += ` AND job_id = ?`
+		args = append(args, jobID)
+	}
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// pruneRunsOverCount keeps only the newest maxRuns runs of each job.
+func (cm *CronManager) pruneRunsOverCount(maxRuns int) (int64, error) {
+	db := cm.currentDB()
+	if db == nil {
+		return 0, fmt.Errorf("database not open")
+	}
+
+	res, err := db.Exec(`DELETE FROM job_runs WHERE id IN (
+        SELECT id FROM (
+          SELECT id, ROW_NUMBER() OVER (PARTITION BY job_id ORDER BY started_at DESC, id DESC) AS rn
+          FROM job_runs
+        ) WHERE rn > ?
+    )`, maxRuns)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// StartRunHistoryPruner starts a goroutine that every interval deletes runs
+// older than maxAge and trims each job to its newest maxRuns runs. A zero
+// maxAge or maxRuns disables that limit.
+func (cm *CronManager) StartRunHistoryPruner(interval, maxAge time.Duration, maxRuns int) {
+	cm.pruner.start(interval, func(context.Context) {
+		cm.pruneRunHistory(maxAge, maxRuns)
+	})
+}
+
+func (cm *CronManager) pruneRunHistory(maxAge time.Duration, maxRuns int) {
+	if maxAge > 0 {
+		n, err := cm.PruneJobRuns("", time.Now().Add(-maxAge))
+		if err != nil {
+			slog.Warn("Run history pruning failed", "error", err, "max_age", maxAge)
+		} else if n > 0 {
+			slog.Info("Pruned old job runs", "count", n, "max_age", maxAge)
+		}
+	}
+	if maxRuns > 0 {
+		n, err := cm.pruneRunsOverCount(maxRuns)
+		if err != nil {
+			slog.Warn("Run history pruning failed", "error", err, "max_runs", maxRuns)
+		} else if n > 0 {
+			slog.Info("Pruned excess job runs", "count", n, "max_runs", maxRuns)
+		}
+	}
+}
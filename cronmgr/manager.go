@@ -2,10 +2,20 @@ package cronmgr
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -24,28 +34,131 @@ const (
 	SyncJob   JobType = "sync"
 	BackupJob JobType = "backup"
 	CustomJob JobType = "custom"
+	QueryJob  JobType = "query"
 )
 
 // Job represents a cron job configuration
 type Job struct {
-	ID           string         `json:"id"`
-	Name         string         `json:"name"`
-	Type         JobType        `json:"type"`
-	Schedule     string         `json:"schedule"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Description is free text from the job's author, unlike ScheduleDesc
+	// which is generated from the schedule
+	Description string       `json:"description,omitempty"`
+	Type        JobType      `json:"type"`
+	Schedule    ScheduleList `json:"schedule"`
+	// Interval, such as "15m", may be given instead of a schedule. The job
+	// then runs on the "@every" schedule derived from it.
+	Interval     string         `json:"interval,omitempty"`
 	ScheduleDesc string         `json:"scheduleDesc,omitempty"`
 	Enabled      bool           `json:"enabled"`
+	Status       JobStatus      `json:"status"`
 	Config       map[string]any `json:"config"`
 	LastRun      *time.Time     `json:"lastRun,omitempty"`
 	NextRun      *time.Time     `json:"nextRun,omitempty"`
-	CronEntryID  *rcron.EntryID `json:"-"`
+	Version      int            `json:"version"`
+	Tags         []string       `json:"tags,omitempty"`
+	CreatedBy    string         `json:"createdBy,omitempty"`
+	CreatedAt    *time.Time     `json:"createdAt,omitempty"`
+	UpdatedAt    *time.Time     `json:"updatedAt,omitempty"`
+	LastError    string         `json:"lastError,omitempty"`
+	LastErrorAt  *time.Time     `json:"lastErrorAt,omitempty"`
+	SuccessCount int64          `json:"successCount"`
+	FailureCount int64          `json:"failureCount"`
+	// LastDriftMs is how late the scheduler fired the job's last scheduled
+	// run, in milliseconds. It isn't persisted.
+	LastDriftMs *int64     `json:"lastDriftMs,omitempty"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+	// LoadError explains why a stored job was loaded but can't be scheduled,
+	// such as its type no longer having an executor
+	LoadError    string          `json:"loadError,omitempty"`
+	CronEntryIDs []rcron.EntryID `json:"-"`
+
+	// dirty marks jobs changed since the last save to the database
+	dirty bool
+	// modifiedAt is when the job last changed in memory, served as
+	// Last-Modified
+	modifiedAt time.Time
 }
 
+// JobStatus summarizes a job's state so a retired or failing job can be told
+// apart from one a user switched off
+type JobStatus string
+
+const (
+	// StatusActive jobs are enabled and their last run, if any, succeeded
+	StatusActive JobStatus = "active"
+	// StatusPaused jobs are enabled but waiting for their notBefore date
+	StatusPaused JobStatus = "paused"
+	// StatusDisabled jobs were turned off by a user
+	StatusDisabled JobStatus = "disabled"
+	// StatusCompleted jobs retired themselves after maxRuns or notAfter
+	StatusCompleted JobStatus = "completed"
+	// StatusError jobs failed their last run or can't be scheduled
+	StatusError JobStatus = "error"
+)
+
+// jobStatus derives a job's status at now. Completed is kept once set until
+// the job is enabled again, since it can't be told from the other fields.
+func jobStatus(job *Job, now time.Time) JobStatus {
+	switch {
+	case job.LoadError != "":
+		return StatusError
+	case !job.Enabled && job.Status == StatusCompleted:
+		return StatusCompleted
+	case !job.Enabled:
+		return StatusDisabled
+	}
+	if notBefore, _, _ := jobWindow(job.Config); now.Before(notBefore) {
+		return StatusPaused
+	}
+	if job.LastError != "" {
+		return StatusError
+	}
+	return StatusActive
+}
+
+// ErrVersionConflict is returned by UpdateJob when the caller's version is
+// older than the stored job, meaning someone else updated it first.
+var ErrVersionConflict = errors.New("job was modified by another update")
+
+// Errors returned when a job is looked up, validated or scheduled. Callers
+// can test for them with errors.Is.
+var (
+	ErrJobNotFound     = errors.New("job not found")
+	ErrUnknownJobType  = errors.New("unknown job type")
+	ErrInvalidConfig   = errors.New("job configuration validation failed")
+	ErrInvalidSchedule = errors.New("invalid schedule")
+	ErrJobLimit        = errors.New("job limit reached")
+	ErrDependencyCycle = errors.New("job dependencies form a cycle")
+	ErrDuplicateName   = errors.New("job name already in use")
+	ErrJobExists       = errors.New("job id already in use")
+	ErrReadOnly        = errors.New("instance is read-only")
+)
+
 // JobExecutor interface for different job types
 type JobExecutor interface {
 	Execute(config map[string]any) error
 	Validate(config map[string]any) error
 }
 
+// OutputExecutor is an optional interface for executors that can be
+// cancelled through a context and report output to keep in run history.
+// executeJob prefers it over Execute when implemented. The context also
+// carries the run's log: see RunLogger and RunOutput.
+type OutputExecutor interface {
+	ExecuteWithOutput(ctx context.Context, config map[string]any) (string, error)
+}
+
+// DryRunExecutor is an optional interface for executors that can describe
+// what a run would do without doing it, for DryRunJob. DryRun may check that
+// the run could succeed but must have no side effects. It gets the config as
+// stored, with secret references unresolved, so its description can be
+// returned to any caller. Job types whose executor doesn't implement it
+// can't be dry run.
+type DryRunExecutor interface {
+	DryRun(ctx context.Context, config map[string]any) (string, error)
+}
+
 // EmailJobExecutor handles email sending jobs
 type EmailJobExecutor struct{}
 
@@ -59,14 +172,22 @@ func (e *EmailJobExecutor) Execute(config map[string]any) error {
 	return nil
 }
 
+func (e *EmailJobExecutor) DryRun(ctx context.Context, config map[string]any) (string, error) {
+	to, _ := config["to"].(string)
+	subject, _ := config["subject"].(string)
+	return fmt.Sprintf("Would send email to %s with subject %q", to, subject), nil
+}
+
 func (e *EmailJobExecutor) Validate(config map[string]any) error {
-	if _, ok := config["to"]; !ok {
-		return fmt.Errorf("'to' field is required")
-	}
-	if _, ok := config["subject"]; !ok {
-		return fmt.Errorf("'subject' field is required")
+	return checkRequired(e.ConfigSchema(), config)
+}
+
+func (e *EmailJobExecutor) ConfigSchema() []ConfigField {
+	return []ConfigField{
+		{Name: "to", Type: FieldString, Required: true, Description: "Recipient address"},
+		{Name: "subject", Type: FieldString, Required: true},
+		{Name: "body", Type: FieldText, Description: "HTML body"},
 	}
-	return nil
 }
 
 // SyncJobExecutor handles data synchronization jobs
@@ -81,14 +202,21 @@ func (s *SyncJobExecutor) Execute(config map[string]any) error {
 	return nil
 }
 
+func (s *SyncJobExecutor) DryRun(ctx context.Context, config map[string]any) (string, error) {
+	source, _ := config["source"].(string)
+	destination, _ := config["destination"].(string)
+	return fmt.Sprintf("Would sync %s to %s", source, destination), nil
+}
+
 func (s *SyncJobExecutor) Validate(config map[string]any) error {
-	if _, ok := config["source"]; !ok {
-		return fmt.Errorf("'source' field is required")
-	}
-	if _, ok := config["destination"]; !ok {
-		return fmt.Errorf("'destination' field is required")
+	return checkRequired(s.ConfigSchema(), config)
+}
+
+func (s *SyncJobExecutor) ConfigSchema() []ConfigField {
+	return []ConfigField{
+		{Name: "source", Type: FieldString, Required: true},
+		{Name: "destination", Type: FieldString, Required: true},
 	}
-	return nil
 }
 
 // BackupJobExecutor handles backup jobs
@@ -103,81 +231,334 @@ func (b *BackupJobExecutor) Execute(config map[string]any) error {
 	return nil
 }
 
-func (b *BackupJobExecutor) Validate(config map[string]any) error {
-	if _, ok := config["path"]; !ok {
-		return fmt.Errorf("'path' field is required")
-	}
-	if _, ok := config["destination"]; !ok {
-		return fmt.Errorf("'destination' field is required")
-	}
-	return nil
+func (b *BackupJobExecutor) DryRun(ctx context.Context, config map[string]any) (string, error) {
+	path, _ := config["path"].(string)
+	destination, _ := config["destination"].(string)
+	return fmt.Sprintf("Would back up %s to %s", path, destination), nil
 }
 
-// CustomJobExecutor handles custom jobs
-type CustomJobExecutor struct{}
-
-func (c *CustomJobExecutor) Execute(config map[string]any) error {
-	command := config["command"].(string)
-
-	slog.Info("Executing custom command", "command", command)
-	// Implement custom command execution here
-	return nil
+func (b *BackupJobExecutor) Validate(config map[string]any) error {
+	return checkRequired(b.ConfigSchema(), config)
 }
 
-func (c *CustomJobExecutor) Validate(config map[string]any) error {
-	if _, ok := config["command"]; !ok {
-		return fmt.Errorf("'command' field is required")
+func (b *BackupJobExecutor) ConfigSchema() []ConfigField {
+	return []ConfigField{
+		{Name: "path", Type: FieldString, Required: true},
+		{Name: "destination", Type: FieldString, Required: true},
 	}
-	return nil
 }
 
 // CronManager manages all cron jobs
 type CronManager struct {
-	cron           *rcron.Cron
-	jobs           map[string]*Job
-	executors      map[JobType]JobExecutor
-	cronDescriptor crondescriptor.ExpressionDescriptor
-	mu             sync.RWMutex
+	config    Config
+	cron      *rcron.Cron
+	jobs      map[string]*Job
+	executors map[JobType]JobExecutor
+	deleted   map[string]*Job     // soft-deleted jobs that can still be restored
+	removed   map[string]struct{} // IDs removed since the last save
+	revision  uint64              // bumped on every job mutation
+	paused    bool                // scheduler stopped for maintenance
+	readOnly  bool                // standby: jobs are served but not scheduled or run
+	started   bool                // Start has run
+	// cronDescriptor and cronDescriptor24h describe schedules with 12-hour
+	// and 24-hour times
+	cronDescriptor    crondescriptor.ExpressionDescriptor
+	cronDescriptor24h crondescriptor.ExpressionDescriptor
+	mu                sync.RWMutex
+	// runCtx is the parent context of job executions, cancelled by Stop
+	runCtx    context.Context
+	runCancel context.CancelFunc
+	// runSlots limits concurrent executions; nil when unlimited
+	runSlots chan struct{}
 	// background sync management
 	syncCancel func()
 	syncWg     sync.WaitGroup
+	// backupStore is the store given to StartBackgroundSync, nil if backups
+	// are disabled. backupMu keeps scheduled and on-demand backups apart.
+	backupStore storage.Storage
+	backupMu    sync.Mutex
+	// backupStatus is the outcome of recent backups. backupInterval and
+	// backupSince are the scheduled backup interval and when this instance
+	// became responsible for backups. All are guarded by mu.
+	backupStatus   BackupStatus
+	backupInterval time.Duration
+	backupSince    time.Time
+	// periodic maintenance loops
+	pruner     periodicTask
+	reconciler periodicTask
+	purger     periodicTask
+	// drift records how late scheduled runs start
+	drift driftHistogram
+	// liveRuns holds the log of each job's in-progress run. followersDone
+	// is closed by StopLogFollowers to end streams of them.
+	liveRuns      map[string]*runLog
+	followersDone chan struct{}
+	stopFollowers sync.Once
+	// observers are notified of job lifecycle events
+	observers []JobObserver
+	// shared SQLite handle, reused across syncs and loads
+	db     *sql.DB
+	dbPath string
+	dbMu   sync.Mutex
 }
 
-func NewCronManager() *CronManager {
+// NewCronManager creates a manager using cfg, with defaults for any unset
+// fields.
+func NewCronManager(cfg Config) *CronManager {
+	cfg = cfg.withDefaults()
+
 	descriptor, err := crondescriptor.NewDescriptor()
 	if err != nil {
 		slog.Error("Failed to create cron descriptor", "error", err)
 		os.Exit(1)
 	}
+	descriptor24h, err := crondescriptor.NewDescriptor(crondescriptor.Use24HourTimeFormat(true))
+	if err != nil {
+		slog.Error("Failed to create cron descriptor", "error", err)
+		os.Exit(1)
+	}
 
-	return &CronManager{
-		cron: rcron.New(rcron.WithSeconds()),
-		jobs: make(map[string]*Job),
+	runCtx, runCancel := context.WithCancel(context.Background())
+	cm := &CronManager{
+		config:    cfg,
+		readOnly:  cfg.ReadOnly,
+		runCtx:    runCtx,
+		runCancel: runCancel,
+		cron:      rcron.New(rcron.WithLocation(cfg.Timezone)),
+		jobs:      make(map[string]*Job),
+		deleted:   make(map[string]*Job),
+		removed:   make(map[string]struct{}),
+		liveRuns:  make(map[string]*runLog),
 		executors: map[JobType]JobExecutor{
 			EmailJob:  &EmailJobExecutor{},
 			SyncJob:   &SyncJobExecutor{},
 			BackupJob: &BackupJobExecutor{},
-			CustomJob: &CustomJobExecutor{},
+			CustomJob: &CustomJobExecutor{
+				AllowedCommands: cfg.CustomJobAllowedCommands,
+				AllowShell:      cfg.CustomJobAllowShell,
+			},
 		},
-		cronDescriptor: *descriptor,
+		cronDescriptor:    *descriptor,
+		cronDescriptor24h: *descriptor24h,
+		followersDone:     make(chan struct{}),
+	}
+	// Query jobs can read any database they reach, so they exist only once
+	// an operator lists the data sources they may use
+	if len(cfg.QueryJobAllowedDSNs) > 0 {
+		cm.executors[QueryJob] = &QueryJobExecutor{
+			AllowedDSNs:    cfg.QueryJobAllowedDSNs,
+			AllowedDrivers: cfg.QueryJobAllowedDrivers,
+			ProtectedPaths: []string{cfg.DBPath},
+		}
+	}
+	if cfg.MaxConcurrentRuns > 0 {
+		cm.runSlots = make(chan struct{}, cfg.MaxConcurrentRuns)
+	}
+
+	// Open the database once up front; syncs and loads reuse this handle.
+	if _, err := cm.database(cfg.DBPath); err != nil {
+		slog.Error("Failed to open database", "error", err, "path", cfg.DBPath)
+		os.Exit(1)
+	}
+
+	return cm
+}
+
+// RegisterExecutor adds an executor for job type t, replacing any existing
+// one, so embedders can run their own job types. Register executors before
+// Start so stored jobs of those types load.
+func (cm *CronManager) RegisterExecutor(t JobType, e JobExecutor) error {
+	if strings.TrimSpace(string(t)) == "" {
+		return fmt.Errorf("job type cannot be empty")
+	}
+	if e == nil {
+		return fmt.Errorf("executor for job type %s cannot be nil", t)
 	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.executors[t] = e
+	return nil
 }
 
 func (cm *CronManager) Start() {
-	// Attempt to load jobs from the default DB file if it exists.
-	const dbPath = "cron_jobs.db"
-	if _, err := os.Stat(dbPath); err == nil {
-		if err := cm.LoadJobsFromDB(dbPath); err != nil {
-			slog.Warn("Failed to load jobs from database", "error", err, "path", dbPath)
+	// Load any jobs persisted in the configured DB file.
+	dbPath := cm.config.DBPath
+	if cm.config.SkipJobLoad {
+		slog.Info("Skipping loading stored jobs", "path", dbPath)
+	} else if err := cm.LoadJobsFromDB(dbPath); err != nil {
+		slog.Warn("Failed to load jobs from database", "error", err, "path", dbPath)
+	}
+
+	cm.mu.Lock()
+	cm.started = true
+	readOnly := cm.readOnly
+	cm.mu.Unlock()
+	if readOnly {
+		slog.Info("Started read-only, jobs will not be scheduled until promoted")
+		return
+	}
+	cm.activate()
+}
+
+// activate starts the scheduler and fires runOnStart jobs and missed runs.
+// It runs once, from Start or from Promote on a read-only manager.
+func (cm *CronManager) activate() {
+	cm.mu.Lock()
+	paused := cm.paused
+	// Startup runs by job ID, with the missed time for catch-up runs; runs
+	// on start have no scheduled time
+	startup := make(map[string]time.Time)
+	now := time.Now()
+	for id, job := range cm.jobs {
+		if !job.Enabled {
+			continue
+		}
+		if runOnStart, _ := jobRunOnStart(job.Config); runOnStart {
+			slog.Info("Running job on start", "job", job.Name, "id", id)
+			startup[id] = time.Time{}
+			continue
+		}
+		// Catch up once on a run missed since the job last ran, or since it
+		// was created if it never has
+		if misfire, _ := jobMisfire(job.Config); misfire != misfireRunOnce {
+			continue
+		}
+		since := job.LastRun
+		if since == nil {
+			since = job.CreatedAt
+		}
+		if since == nil {
+			continue
+		}
+		if missed := cm.missedRun(job, *since, now); !missed.IsZero() {
+			slog.Info("Running missed job", "job", job.Name, "id", id, "missed", missed, "last_run", since)
+			startup[id] = missed
+		}
+	}
+	cm.mu.Unlock()
+
+	if !paused {
+		cm.cron.Start()
+	}
+	cm.refreshNextRuns()
+
+	// executeJob waits for a free slot, so startup and catch-up runs respect
+	// MaxConcurrentRuns like scheduled ones
+	for id, scheduled := range startup {
+		go cm.executeJob(id, scheduled)
+	}
+}
+
+// Promote makes a read-only manager active: its enabled jobs are scheduled
+// and, if it has started, runOnStart jobs and missed runs fire as they would
+// at startup. Promoting an active manager does nothing.
+func (cm *CronManager) Promote() {
+	cm.mu.Lock()
+	if !cm.readOnly {
+		cm.mu.Unlock()
+		return
+	}
+	cm.readOnly = false
+	// Backups were the active instance's job until now
+	cm.backupSince = time.Now()
+	for _, job := range cm.jobs {
+		if !job.Enabled || len(job.CronEntryIDs) > 0 {
+			continue
+		}
+		if err := cm.scheduleLocked(job); err != nil {
+			slog.Error("Failed to schedule job on promotion", "job", job.Name, "id", job.ID, "error", err)
+		}
+	}
+	started := cm.started
+	cm.mu.Unlock()
+
+	slog.Info("Promoted from read-only to active")
+	if started {
+		cm.activate()
+	}
+}
+
+// ReadOnly reports whether the manager is a read-only standby
+func (cm *CronManager) ReadOnly() bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.readOnly
+}
+
+// PauseScheduling stops the scheduler for maintenance so no job fires until
+// ResumeScheduling. Jobs and their cron entries are kept; runs already in
+// progress finish normally.
+func (cm *CronManager) PauseScheduling() {
+	cm.mu.Lock()
+	if cm.paused {
+		cm.mu.Unlock()
+		return
+	}
+	cm.paused = true
+	cm.cron.Stop()
+	cm.mu.Unlock()
+
+	slog.Info("Scheduling paused for maintenance")
+}
+
+// ResumeScheduling restarts the scheduler after PauseScheduling. Runs missed
+// while paused are not made up.
+func (cm *CronManager) ResumeScheduling() {
+	cm.mu.Lock()
+	if !cm.paused {
+		cm.mu.Unlock()
+		return
+	}
+	cm.paused = false
+	if !cm.readOnly {
+		cm.cron.Start()
+	}
+	cm.mu.Unlock()
+
+	cm.refreshNextRuns()
+	slog.Info("Scheduling resumed")
+}
+
+// Paused reports whether scheduling is paused for maintenance
+func (cm *CronManager) Paused() bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.paused
+}
+
+// refreshNextRuns recomputes NextRun from the scheduler for enabled jobs and
+// clears it for disabled ones, so values loaded from the database are never
+// served stale after a restart.
+func (cm *CronManager) refreshNextRuns() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	for _, job := range cm.jobs {
+		var next *time.Time
+		if job.Enabled {
+			next = cm.nextRunLocked(job)
+		}
+		if !sameTime(job.NextRun, next) {
+			job.NextRun = next
+			cm.markChanged(job)
 		}
 	}
+}
 
-	cm.cron.Start()
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
 }
 
 // StartBackgroundSync starts a goroutine that periodically syncs in-memory jobs
-// to the provided DB path and backs up to blob storage if configured.
-func (cm *CronManager) StartBackgroundSync(dbPath string, syncInterval time.Duration, backupInterval time.Duration, blobName string, backupStore storage.Storage) {
+// to the configured DB path and backs up to blob storage if backupStore is set.
+func (cm *CronManager) StartBackgroundSync(syncInterval time.Duration, backupInterval time.Duration, backupStore storage.Storage) {
+	dbPath := cm.config.DBPath
+	blobName := cm.config.BackupBlobName
+
 	// cancel existing if running
 	if cm.syncCancel != nil {
 		cm.syncCancel()
@@ -186,6 +567,11 @@ func (cm *CronManager) StartBackgroundSync(dbPath string, syncInterval time.Dura
 
 	ctx, cancel := context.WithCancel(context.Background())
 	cm.syncCancel = cancel
+	cm.backupStore = backupStore
+	cm.mu.Lock()
+	cm.backupInterval = backupInterval
+	cm.backupSince = time.Now()
+	cm.mu.Unlock()
 	cm.syncWg.Add(1)
 
 	go func() {
@@ -203,6 +589,41 @@ func (cm *CronManager) StartBackgroundSync(dbPath string, syncInterval time.Dura
 		}
 		// If backup is not enabled, backupChan remains nil, which is safe in select
 
+		// A failed backup is retried a few times with growing delays before
+		// waiting for the next interval. retryChan is nil when no retry is due.
+		var retryTimer *time.Timer
+		var retryChan <-chan time.Time
+		retries := 0
+		defer func() {
+			if retryTimer != nil {
+				retryTimer.Stop()
+			}
+		}()
+		runBackup := func() {
+			retryChan = nil
+			// A standby must not overwrite the active instance's backups
+			if cm.ReadOnly() {
+				retries = 0
+				return
+			}
+			_, err := cm.backup(ctx)
+			if err == nil || ctx.Err() != nil {
+				retries = 0
+				return
+			}
+			if retries == backupRetries {
+				slog.Warn("Backup failed, giving up until the next interval", "error", err, "path", dbPath, "blob", blobName, "attempts", retries+1)
+				retries = 0
+				return
+			}
+			delay := backupRetryDelay << retries
+			retries++
+			slog.Warn("Backup failed, retrying", "error", err, "path", dbPath, "blob", blobName, "retry_in", delay, "attempt", retries)
+			retryTimer = time.NewTimer(delay)
+			retryChan = retryTimer.C
+			cm.setBackupRetry(time.Now().Add(delay))
+		}
+
 		for {
 			select {
 			case <-ctx.Done():
@@ -212,188 +633,1265 @@ func (cm *CronManager) StartBackgroundSync(dbPath string, syncInterval time.Dura
 					slog.Warn("Background sync failed", "error", err, "path", dbPath)
 				}
 			case <-backupChan:
-				if err := backup.BackupSQLite(ctx, dbPath, blobName, backupStore); err != nil {
-					slog.Warn("Backup failed", "error", err, "path", dbPath, "blob", blobName)
+				// A pending retry stands in for this backup
+				if retryChan != nil {
+					continue
 				}
+				runBackup()
+			case <-retryChan:
+				runBackup()
 			}
 		}
 	}()
 }
 
 func (cm *CronManager) Stop() {
-	// Try to persist current jobs to disk before stopping the scheduler.
-	const dbPath = "cron_jobs.db"
-	if err := cm.SaveAllJobsToDB(dbPath); err != nil {
-		slog.Warn("Failed to save jobs to database", "error", err, "path", dbPath)
-	}
-
-	cm.cron.Stop()
-
-	// stop background sync if running
+	ctx, cancel := context.WithTimeout(context.Background(), cm.config.ShutdownTimeout)
+	defer cancel()
+
+	// Stop scheduling and abort executions still in flight; running jobs
+	// then get until the deadline to return so their results make the final
+	// save. The background loops are stopped alongside them.
+	runsDone := cm.cron.Stop()
+	cm.runCancel()
 	if cm.syncCancel != nil {
 		cm.syncCancel()
-		cm.syncWg.Wait()
-		cm.syncCancel = nil
 	}
-}
-
-func (cm *CronManager) AddJob(job *Job) error {
-	cm.mu.Lock()
-	defer cm.mu.Unlock()
-
-	executor, ok := cm.executors[job.Type]
-	if !ok {
-		return fmt.Errorf("unknown job type: %s", job.Type)
+	steps := []struct {
+		name string
+		done <-chan struct{}
+	}{
+		{"running jobs", runsDone.Done()},
+		{"background sync", goDone(cm.syncWg.Wait)},
+		{"run history pruner", goDone(cm.pruner.stop)},
+		{"reconciler", goDone(cm.reconciler.stop)},
+		{"deleted job purger", goDone(cm.purger.stop)},
 	}
-
-	if err := executor.Validate(job.Config); err != nil {
-		return fmt.Errorf("job configuration validation failed: %w", err)
+	finished := true
+	for _, step := range steps {
+		select {
+		case <-step.done:
+			continue
+		default:
+		}
+		select {
+		case <-step.done:
+		case <-ctx.Done():
+			slog.Warn("Shutdown timed out waiting", "for", step.name, "timeout", cm.config.ShutdownTimeout)
+			finished = false
+		}
 	}
+	cm.syncCancel = nil
 
-	// Generate human-readable description of the cron schedule
-	description, err := cm.cronDescriptor.ToDescription(job.Schedule, crondescriptor.Locale_en)
-	if err != nil {
-		slog.Warn("Could not generate schedule description", "schedule", job.Schedule, "error", err)
-		job.ScheduleDesc = job.Schedule
-	} else {
-		job.ScheduleDesc = description
+	// Persist current jobs even if something is stuck
+	dbPath := cm.config.DBPath
+	if err := cm.SaveAllJobsToDB(dbPath); err != nil {
+		slog.Warn("Failed to save jobs to database", "error", err, "path", dbPath)
 	}
 
-	if job.Enabled {
-		entryID, err := cm.cron.AddFunc(job.Schedule, func() {
-			cm.executeJob(job.ID)
-		})
-		if err != nil {
-			return fmt.Errorf("failed to schedule job: %w", err)
-		}
-		job.CronEntryID = &entryID
-
-		// Get next run time
-		entry := cm.cron.Entry(entryID)
-		nextRun := entry.Next
-		job.NextRun = &nextRun
+	// A stuck step may still be using the database, so leave it open
+	if !finished {
+		return
 	}
+	if err := cm.closeDB(); err != nil {
+		slog.Warn("Failed to close database", "error", err)
+	}
+}
 
-	cm.jobs[job.ID] = job
-	return nil
+// goDone runs fn in a goroutine and returns a channel closed when it returns
+func goDone(fn func()) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+	return done
 }
 
-func (cm *CronManager) executeJob(jobID string) {
-	cm.mu.RLock()
-	job, exists := cm.jobs[jobID]
-	if !exists {
-		cm.mu.RUnlock()
-		return
-	}
-	// Get job details while holding read lock
-	jobName := job.Name
-	jobType := job.Type
-	jobEnabled := job.Enabled
-	config := job.Config
-	executor := cm.executors[jobType]
-	cm.mu.RUnlock()
+// ErrBackupDisabled is returned by BackupNow when no backup store is set
+var ErrBackupDisabled = errors.New("backups are not configured")
 
-	if !jobEnabled {
-		return
+// BackupNow saves all jobs and backs the database up to the backup store
+// right away, returning the blob and checksum or that it was unchanged.
+func (cm *CronManager) BackupNow(ctx context.Context) (backup.Result, error) {
+	if cm.backupStore == nil || cm.config.BackupBlobName == "" {
+		return backup.Result{}, ErrBackupDisabled
+	}
+	dbPath := cm.config.DBPath
+	if err := cm.SaveAllJobsToDB(dbPath); err != nil {
+		return backup.Result{}, fmt.Errorf("save jobs: %w", err)
 	}
 
-	slog.Info("Executing job", "job", jobName, "type", jobType, "id", jobID)
+	return cm.backup(ctx)
+}
 
-	// Execute job outside of lock to avoid blocking other operations
-	if err := executor.Execute(config); err != nil {
-		slog.Error("Job execution failed", "job", jobName, "id", jobID, "error", err)
-	} else {
-		slog.Info("Job executed successfully", "job", jobName, "id", jobID)
-	}
+// backupRetries is how many times a failed scheduled backup is retried
+// before waiting for the next interval
+const backupRetries = 3
+
+// backupRetryDelay is the wait before the first retry of a failed backup;
+// it doubles for each further retry
+const backupRetryDelay = 30 * time.Second
+
+// BackupStatus reports the outcome of recent backups, scheduled or on
+// demand. A backup skipped because the database was unchanged counts as a
+// success.
+type BackupStatus struct {
+	LastAttemptAt *time.Time `json:"lastAttemptAt,omitempty"`
+	LastSuccessAt *time.Time `json:"lastSuccessAt,omitempty"`
+	// LastChecksum is the MD5 checksum of the database at the last success
+	LastChecksum string `json:"lastChecksum,omitempty"`
+	// LastError is the error of the last attempt, empty if it succeeded
+	LastError string `json:"lastError,omitempty"`
+	// ConsecutiveFailures counts failed attempts since the last success
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+	// RetryAt is when a failed backup will next be retried, if it will be
+	// before the next interval
+	RetryAt *time.Time `json:"retryAt,omitempty"`
+	// Warning is set when no backup has succeeded for Config.BackupStaleFactor
+	// backup intervals
+	Warning string `json:"warning,omitempty"`
+}
 
-	// Update job state with write lock
-	cm.mu.Lock()
-	job, exists = cm.jobs[jobID]
-	if !exists {
-		cm.mu.Unlock()
-		return
-	}
+// backup backs the database up to the backup store and records the outcome
+// in the backup status
+func (cm *CronManager) backup(ctx context.Context) (backup.Result, error) {
+	cm.backupMu.Lock()
+	result, err := backup.BackupSQLite(ctx, cm.config.DBPath, cm.config.BackupBlobName, cm.backupStore)
+	cm.backupMu.Unlock()
 
 	now := time.Now()
-	job.LastRun = &now
-
-	// Update next run time if scheduled
-	if job.CronEntryID != nil {
-		entry := cm.cron.Entry(*job.CronEntryID)
-		nextRun := entry.Next
-		job.NextRun = &nextRun
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	status := &cm.backupStatus
+	status.LastAttemptAt = &now
+	status.RetryAt = nil
+	if err != nil {
+		status.LastError = err.Error()
+		status.ConsecutiveFailures++
+	} else {
+		status.LastSuccessAt = &now
+		status.LastChecksum = result.Checksum
+		status.LastError = ""
+		status.ConsecutiveFailures = 0
 	}
-	cm.mu.Unlock()
+	return result, err
 }
 
-func (cm *CronManager) RemoveJob(jobID string) error {
+// setBackupRetry records when a failed backup will be retried
+func (cm *CronManager) setBackupRetry(at time.Time) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
+	cm.backupStatus.RetryAt = &at
+}
 
-	job, exists := cm.jobs[jobID]
-	if !exists {
-		return fmt.Errorf("job not found: %s", jobID)
+// BackupStatus returns the outcome of recent backups, with a warning if they
+// have stopped succeeding
+func (cm *CronManager) BackupStatus() (BackupStatus, error) {
+	if cm.backupStore == nil || cm.config.BackupBlobName == "" {
+		return BackupStatus{}, ErrBackupDisabled
 	}
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	status := cm.backupStatus
+	status.Warning = cm.backupWarningLocked(time.Now())
+	return status, nil
+}
 
-	if job.CronEntryID != nil {
-		cm.cron.Remove(*job.CronEntryID)
+// backupWarningLocked explains why backups look stale at now, or returns ""
+// if they don't. A standby makes no backups, so they are never stale. Must
+// be called with cm.mu held.
+func (cm *CronManager) backupWarningLocked(now time.Time) string {
+	if cm.readOnly || cm.backupInterval <= 0 {
+		return ""
+	}
+	since := cm.backupSince
+	if last := cm.backupStatus.LastSuccessAt; last != nil && last.After(since) {
+		since = *last
 	}
+	limit := time.Duration(cm.config.BackupStaleFactor) * cm.backupInterval
+	if now.Sub(since) <= limit {
+		return ""
+	}
+	if last := cm.backupStatus.LastSuccessAt; last != nil {
+		return fmt.Sprintf("last successful backup was %s ago, more than %d backup intervals", now.Sub(*last).Round(time.Second), cm.config.BackupStaleFactor)
+	}
+	return fmt.Sprintf("no backup has succeeded in %s, more than %d backup intervals", now.Sub(since).Round(time.Second), cm.config.BackupStaleFactor)
+}
 
-	delete(cm.jobs, jobID)
-	return nil
+// BackupHealth is the backup summary reported by the health check
+type BackupHealth struct {
+	LastSuccessAt *time.Time `json:"lastSuccessAt,omitempty"`
+	LastChecksum  string     `json:"lastChecksum,omitempty"`
+	Warning       string     `json:"warning,omitempty"`
 }
 
-func (cm *CronManager) UpdateJob(jobID string, updatedJob *Job) error {
-	cm.mu.RLock()
-	// Validate the new job before removing the old one
-	executor, ok := cm.executors[updatedJob.Type]
-	if !ok {
-		cm.mu.RUnlock()
-		return fmt.Errorf("unknown job type: %s", updatedJob.Type)
+// BackupHealth summarizes backups for the health check, or returns nil if
+// backups are disabled
+func (cm *CronManager) BackupHealth() *BackupHealth {
+	status, err := cm.BackupStatus()
+	if err != nil {
+		return nil
 	}
-	cm.mu.RUnlock()
+	return &BackupHealth{LastSuccessAt: status.LastSuccessAt, LastChecksum: status.LastChecksum, Warning: status.Warning}
+}
 
-	// Validate configuration before removing existing job
-	if err := executor.Validate(updatedJob.Config); err != nil {
-		return fmt.Errorf("job configuration validation failed: %w", err)
+// ListBackups returns the database backups in the backup blob's folder,
+// newest first. Checksum files stored next to backups are left out.
+func (cm *CronManager) ListBackups(ctx context.Context) ([]storage.FileInfo, error) {
+	if cm.backupStore == nil || cm.config.BackupBlobName == "" {
+		return nil, ErrBackupDisabled
 	}
-
-	// Validate schedule format (basic check - cron library will validate fully)
-	if updatedJob.Schedule == "" {
-		return fmt.Errorf("schedule cannot be empty")
+	prefix := cm.config.BackupBlobName
+	if dir := path.Dir(prefix); dir != "." {
+		prefix = dir + "/"
 	}
-
-	// Now safe to remove and add
-	if err := cm.RemoveJob(jobID); err != nil {
-		return err
+	files, err := cm.backupStore.ListFiles(ctx, prefix)
+	if err != nil {
+		return nil, err
 	}
 
-	// Ensure the ID matches
-	updatedJob.ID = jobID
-	return cm.AddJob(updatedJob)
+	backups := make([]storage.FileInfo, 0, len(files))
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name, ".md5") {
+			backups = append(backups, f)
+		}
+	}
+	// Files without a timestamp go last
+	storage.SortFiles(backups, storage.SortByModified, "desc")
+	return backups, nil
 }
 
-func (cm *CronManager) GetJob(jobID string) (*Job, error) {
+// AddJob validates and schedules a new job. It fails with ErrJobExists if a
+// job with the same ID exists, since replacing one is UpdateJob's job, and
+// with ErrJobLimit if Config.MaxJobs jobs already exist.
+func (cm *CronManager) AddJob(job *Job) error {
+	if err := applyInterval(job); err != nil {
+		return err
+	}
+	if err := cm.checkScheduleHorizons(job); err != nil {
+		return err
+	}
+	job.Status = ""
 	cm.mu.RLock()
-	defer cm.mu.RUnlock()
-
-	job, exists := cm.jobs[jobID]
-	if !exists {
-		return nil, fmt.Errorf("job not found: %s", jobID)
+	runNow := cm.started && !cm.paused && !cm.readOnly
+	cm.mu.RUnlock()
+	if err := cm.addJob(job, true, true); err != nil {
+		return err
+	}
+	added := cm.jobSnapshot(job)
+	cm.notify(func(o JobObserver) { o.OnJobAdded(added) })
+
+	// A new runOnStart job added while running fires once right away, as it
+	// would have at startup
+	if runOnStart, _ := jobRunOnStart(job.Config); runNow && runOnStart && job.Enabled {
+		slog.Info("Running job on start", "job", job.Name, "id", job.ID)
+		go cm.executeJob(job.ID, time.Time{})
 	}
-	return job, nil
+	return nil
 }
 
-func (cm *CronManager) GetAllJobs() []*Job {
-	cm.mu.RLock()
-	defer cm.mu.RUnlock()
+// addJob does the work of AddJob, refusing IDs already in use. Loads from
+// the database pass enforceLimit=false so existing jobs are never dropped
+// for being over the cap, and checkName=false so names duplicated before
+// Config.RequireUniqueNames was set still load.
+func (cm *CronManager) addJob(job *Job, enforceLimit, checkName bool) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if _, exists := cm.jobs[job.ID]; exists {
+		return fmt.Errorf("%w: %s", ErrJobExists, job.ID)
+	}
+	return cm.addJobLocked(job, enforceLimit, checkName)
+}
+
+// addJobLocked is addJob for callers that hold cm.mu for writing
+func (cm *CronManager) addJobLocked(job *Job, enforceLimit, checkName bool) error {
+	if _, exists := cm.deleted[job.ID]; exists {
+		return fmt.Errorf("%w: id %s belongs to a deleted job", ErrInvalidConfig, job.ID)
+	}
+	if _, exists := cm.jobs[job.ID]; enforceLimit && !exists && cm.config.MaxJobs > 0 && len(cm.jobs) >= cm.config.MaxJobs {
+		return fmt.Errorf("%w: at most %d jobs are allowed", ErrJobLimit, cm.config.MaxJobs)
+	}
+	if checkName {
+		if err := cm.checkNameLocked(job.ID, job.Name); err != nil {
+			return err
+		}
+	}
+	if err := cm.validateJobLocked(job); err != nil {
+		return err
+	}
+
+	for i, expr := range job.Schedule {
+		job.Schedule[i] = expandSchedulePreset(expr, cm.useSeconds(job.Config))
+	}
+
+	// Generate human-readable description of each cron schedule
+	descriptions := make([]string, 0, len(job.Schedule))
+	for _, expr := range job.Schedule {
+		description, err := cm.describeExpr(expr, cm.useSeconds(job.Config), cm.config.Use24HourTime)
+		if err != nil {
+			slog.Warn("Could not generate schedule description", "schedule", expr, "error", err)
+			description = expr
+		}
+		descriptions = append(descriptions, description)
+	}
+	job.ScheduleDesc = strings.Join(descriptions, "; ")
+
+	if job.Enabled {
+		// Jobs updated without a schedule change arrive with their
+		// existing cron entries. Read-only managers schedule nothing.
+		if len(job.CronEntryIDs) == 0 && !cm.readOnly {
+			if err := cm.scheduleLocked(job); err != nil {
+				return err
+			}
+		}
+	} else {
+		// Disabled jobs have no upcoming run
+		job.CronEntryIDs = nil
+		job.NextRun = nil
+	}
+
+	if job.Version == 0 {
+		job.Version = 1
+	}
+	job.Tags = normalizeTags(job.Tags)
+	cm.markChanged(job)
+	cm.jobs[job.ID] = job
+	delete(cm.removed, job.ID)
+	return nil
+}
+
+// validateJobLocked checks a job's type, config, options, dependencies and
+// schedule the way adding it would, without changing anything. Must be
+// called with cm.mu held.
+func (cm *CronManager) validateJobLocked(job *Job) error {
+	executor, ok := cm.executors[job.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJobType, job.Type)
+	}
+
+	if err := executor.Validate(job.Config); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if err := validateJobOptions(job.Config); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	if err := cm.checkDependencyCycleLocked(job); err != nil {
+		return err
+	}
+
+	if len(job.Schedule) == 0 {
+		return fmt.Errorf("%w: schedule cannot be empty", ErrInvalidSchedule)
+	}
+	// Enabled jobs are scheduled, so their expressions must parse
+	if job.Enabled {
+		for _, expr := range job.Schedule {
+			if _, err := cm.parseSchedule(expr, job.Config); err != nil {
+				return fmt.Errorf("%w: %q: %w", ErrInvalidSchedule, expr, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkNameLocked returns ErrDuplicateName if Config.RequireUniqueNames is
+// set and a job other than id is already called name. Must be called with
+// cm.mu held.
+func (cm *CronManager) checkNameLocked(id, name string) error {
+	if !cm.config.RequireUniqueNames {
+		return nil
+	}
+	for _, other := range cm.jobs {
+		if other.ID != id && other.Name == name {
+			return fmt.Errorf("%w: %q is job %s", ErrDuplicateName, name, other.ID)
+		}
+	}
+	return nil
+}
+
+// scheduleLocked registers a cron entry for each of the job's schedules and
+// sets its NextRun. If any schedule is rejected none are registered. Must be
+// called with cm.mu held for writing.
+func (cm *CronManager) scheduleLocked(job *Job) error {
+	jobID := job.ID
+	entryIDs := make([]rcron.EntryID, 0, len(job.Schedule))
+	for _, expr := range job.Schedule {
+		sched, err := cm.parseSchedule(expr, job.Config)
+		if err != nil {
+			for _, id := range entryIDs {
+				cm.cron.Remove(id)
+			}
+			return fmt.Errorf("%w: %q: %w", ErrInvalidSchedule, expr, err)
+		}
+		entryID := cm.cron.Schedule(sched, rcron.FuncJob(func() {
+			cm.runScheduled(jobID)
+		}))
+		entryIDs = append(entryIDs, entryID)
+	}
+	job.CronEntryIDs = entryIDs
+
+	// Get next run time. Before the scheduler starts the entries have no
+	// next time yet; refreshNextRuns fills it in once it does.
+	job.NextRun = cm.nextRunLocked(job)
+	return nil
+}
+
+// unscheduleEntriesLocked removes all of a job's cron entries. Must be called
+// with cm.mu held for writing.
+func (cm *CronManager) unscheduleEntriesLocked(job *Job) {
+	for _, id := range job.CronEntryIDs {
+		cm.cron.Remove(id)
+	}
+	job.CronEntryIDs = nil
+}
+
+// nextRunLocked returns the earliest next fire time across a job's cron
+// entries, or nil if none is known. Must be called with cm.mu held.
+func (cm *CronManager) nextRunLocked(job *Job) *time.Time {
+	var next *time.Time
+	for _, id := range job.CronEntryIDs {
+		entry := cm.cron.Entry(id)
+		if entry.Next.IsZero() {
+			continue
+		}
+		if next == nil || entry.Next.Before(*next) {
+			t := entry.Next
+			next = &t
+		}
+	}
+	return next
+}
+
+// entriesRegisteredLocked reports whether the job has a live cron entry for
+// every schedule. Must be called with cm.mu held.
+func (cm *CronManager) entriesRegisteredLocked(job *Job) bool {
+	if len(job.CronEntryIDs) != len(job.Schedule) {
+		return false
+	}
+	for _, id := range job.CronEntryIDs {
+		if !cm.cron.Entry(id).Valid() {
+			return false
+		}
+	}
+	return true
+}
+
+// ReconcileResult summarizes a Reconcile pass
+type ReconcileResult struct {
+	Checked     int `json:"checked"`
+	Missing     int `json:"missing"`
+	Rescheduled int `json:"rescheduled"`
+	Stale       int `json:"stale"`
+	Failed      int `json:"failed"`
+}
+
+// Reconcile verifies that every enabled job has a live cron entry and
+// re-registers any that are missing. Entries left behind by disabled jobs
+// are removed. Read-only managers have no entries to check.
+func (cm *CronManager) Reconcile() ReconcileResult {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	var result ReconcileResult
+	if cm.readOnly {
+		return result
+	}
+	for _, job := range cm.jobs {
+		result.Checked++
+		registered := cm.entriesRegisteredLocked(job)
+
+		switch {
+		case job.Enabled && !registered:
+			result.Missing++
+			slog.Warn("Enabled job is missing cron entries, rescheduling", "job", job.Name, "id", job.ID)
+			cm.unscheduleEntriesLocked(job)
+			if err := cm.scheduleLocked(job); err != nil {
+				result.Failed++
+				slog.Error("Failed to reschedule job", "job", job.Name, "id", job.ID, "error", err)
+				continue
+			}
+			result.Rescheduled++
+			cm.markChanged(job)
+		case !job.Enabled && len(job.CronEntryIDs) > 0:
+			result.Stale++
+			slog.Warn("Disabled job still has cron entries, removing", "job", job.Name, "id", job.ID)
+			cm.unscheduleEntriesLocked(job)
+			job.NextRun = nil
+			cm.markChanged(job)
+		}
+	}
+
+	if result.Missing > 0 || result.Stale > 0 {
+		slog.Info("Reconcile found discrepancies", "checked", result.Checked, "missing", result.Missing, "rescheduled", result.Rescheduled, "stale", result.Stale, "failed", result.Failed)
+	}
+	return result
+}
+
+// StartReconciler runs Reconcile every interval in the background
+func (cm *CronManager) StartReconciler(interval time.Duration) {
+	cm.reconciler.start(interval, func(context.Context) {
+		cm.Reconcile()
+	})
+}
+
+// executeJob runs a job now. scheduled is the firing it was scheduled for,
+// which instances sharing a RunLocker claim before running, or the zero time
+// for unscheduled runs.
+func (cm *CronManager) executeJob(jobID string, scheduled time.Time) {
+	cm.mu.RLock()
+	job, exists := cm.jobs[jobID]
+	if !exists {
+		cm.mu.RUnlock()
+		return
+	}
+	// Get job details while holding read lock
+	snapshot := *job
+	jobName := job.Name
+	jobType := job.Type
+	jobEnabled := job.Enabled
+	config := job.Config
+	executor := cm.executors[jobType]
+	readOnly := cm.readOnly
+	cm.mu.RUnlock()
+
+	if !jobEnabled || readOnly {
+		return
+	}
+
+	// Only run inside the job's notBefore/notAfter window, retiring it once
+	// the window has closed
+	notBefore, notAfter, _ := jobWindow(config)
+	if now := time.Now(); !notBefore.IsZero() && now.Before(notBefore) {
+		slog.Info("Skipping job run before its start date", "job", jobName, "id", jobID, "not_before", notBefore)
+		return
+	} else if !notAfter.IsZero() && now.After(notAfter) {
+		slog.Info("Job is past its end date, disabling", "job", jobName, "id", jobID, "not_after", notAfter)
+		cm.mu.Lock()
+		if job, exists := cm.jobs[jobID]; exists && job.Enabled {
+			cm.completeLocked(job)
+		}
+		cm.mu.Unlock()
+		cm.persistDisabled(jobName, jobID)
+		return
+	}
+
+	// Only run once every dependency has succeeded recently
+	if dep, ok := cm.dependenciesMet(config); !ok {
+		slog.Info("Skipping job run, dependency has not succeeded recently", "job", jobName, "id", jobID, "dependency", dep)
+		return
+	}
+
+	// In a cluster only the instance that claims a firing runs it
+	if !scheduled.IsZero() && !cm.claimRun(jobName, jobID, scheduled) {
+		return
+	}
+
+	// Spread out jobs sharing a schedule by waiting a random delay first
+	if jitter, _ := jobJitter(config); jitter > 0 {
+		delay := rand.N(jitter)
+		slog.Debug("Delaying job start", "job", jobName, "id", jobID, "delay", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-cm.runCtx.Done():
+			timer.Stop()
+			return
+		}
+	}
+
+	// Wait for a free execution slot when concurrency is capped
+	if cm.runSlots != nil {
+		select {
+		case cm.runSlots <- struct{}{}:
+			defer func() { <-cm.runSlots }()
+		case <-cm.runCtx.Done():
+			return
+		}
+	}
+
+	// The run's own log records and output are kept with its history
+	live := cm.startRunLog(jobID)
+	defer cm.endRunLog(jobID, live)
+	runCtx := withUnresolvedConfig(withRunLog(cm.runCtx, live), config)
+	logger := RunLogger(runCtx)
+	logger.Info("Executing job", "job", jobName, "type", jobType, "id", jobID)
+	cm.notify(func(o JobObserver) { o.OnJobStart(snapshot) })
+
+	// Execute job outside of lock to avoid blocking other operations
+	startedAt := time.Now()
+	var output string
+	runConfig, execErr := cm.resolveSecrets(config)
+	if execErr != nil {
+		execErr = fmt.Errorf("resolve secrets: %w", execErr)
+	} else if oe, ok := executor.(OutputExecutor); ok {
+		output, execErr = oe.ExecuteWithOutput(runCtx, runConfig)
+	} else {
+		execErr = executor.Execute(runConfig)
+	}
+	live.addOutput(output)
+	if execErr != nil {
+		logger.Error("Job execution failed", "job", jobName, "id", jobID, "error", execErr)
+	} else {
+		logger.Info("Job executed successfully", "job", jobName, "id", jobID)
+	}
+
+	run := JobRun{JobID: jobID, StartedAt: startedAt, FinishedAt: time.Now(), Success: execErr == nil, Output: output}
+	if execErr != nil {
+		run.Error = execErr.Error()
+	}
+	if folder, _ := jobUploadOutputTo(config); folder != "" && output != "" {
+		url, err := cm.uploadRunOutput(folder, jobID, startedAt, output)
+		if err != nil {
+			logger.Warn("Failed to upload job output", "job", jobName, "id", jobID, "error", err)
+		}
+		run.OutputURL = url
+	}
+	run.Log = live.String()
+	if _, err := cm.recordRun(run); err != nil {
+		slog.Warn("Failed to record job run", "job", jobName, "id", jobID, "error", err)
+	}
+
+	// Let uptime monitors know the run succeeded
+	if pingURL, _ := jobPingOnSuccessURL(runConfig); pingURL != "" && execErr == nil {
+		go cm.pingSuccess(pingURL, jobName, jobID)
+	}
+
+	// Update job state with write lock
+	duration := run.FinishedAt.Sub(startedAt)
+	cm.mu.Lock()
+	job, exists = cm.jobs[jobID]
+	if !exists {
+		cm.mu.Unlock()
+		cm.notifyRunEnd(snapshot, duration, execErr)
+		return
+	}
+
+	now := time.Now()
+	job.LastRun = &now
+	if execErr != nil {
+		job.LastError = execErr.Error()
+		job.LastErrorAt = &now
+		job.FailureCount++
+	} else {
+		job.LastError = ""
+		job.LastErrorAt = nil
+		job.SuccessCount++
+	}
+	cm.markChanged(job)
+
+	// Retire jobs that have used up their run allowance
+	exhausted := false
+	if maxRuns, _ := jobMaxRuns(job.Config); maxRuns > 0 && job.SuccessCount+job.FailureCount >= maxRuns {
+		slog.Info("Job reached its run limit, disabling", "job", job.Name, "id", jobID, "max_runs", maxRuns)
+		cm.completeLocked(job)
+		exhausted = true
+	}
+
+	// Update next run time if scheduled
+	if next := cm.nextRunLocked(job); next != nil {
+		job.NextRun = next
+	}
+	finished := *job
+	cm.mu.Unlock()
+	cm.notifyRunEnd(finished, duration, execErr)
+
+	if exhausted {
+		cm.persistDisabled(jobName, jobID)
+	}
+}
+
+// dependenciesMet reports whether every job in the config's dependsOn list
+// has a successful run within the dependsOnWindow. If not, it returns the
+// first dependency that hasn't.
+func (cm *CronManager) dependenciesMet(config map[string]any) (string, bool) {
+	deps, _ := jobDependsOn(config)
+	if len(deps) == 0 {
+		return "", true
+	}
+	window, _ := jobDependsOnWindow(config)
+	cutoff := time.Now().Add(-window)
+	for _, dep := range deps {
+		at, err := cm.lastSuccessAt(dep)
+		if err != nil {
+			slog.Warn("Failed to read dependency run history", "dependency", dep, "error", err)
+			return dep, false
+		}
+		if at.Before(cutoff) {
+			return dep, false
+		}
+	}
+	return "", true
+}
+
+// checkDependencyCycleLocked rejects job if following dependsOn from it,
+// through the jobs already registered, leads back to it. Dependencies that
+// don't exist yet are allowed. Must be called with cm.mu held.
+func (cm *CronManager) checkDependencyCycleLocked(job *Job) error {
+	deps, _ := jobDependsOn(job.Config)
+	visited := make(map[string]bool)
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		if id == job.ID {
+			return true
+		}
+		if visited[id] {
+			return false
+		}
+		visited[id] = true
+		other, ok := cm.jobs[id]
+		if !ok {
+			return false
+		}
+		next, _ := jobDependsOn(other.Config)
+		return slices.ContainsFunc(next, visit)
+	}
+	for _, dep := range deps {
+		if visit(dep) {
+			return fmt.Errorf("%w: %s depends on %s", ErrDependencyCycle, job.ID, dep)
+		}
+	}
+	return nil
+}
+
+// pingTimeout bounds how long a success ping may take
+const pingTimeout = 10 * time.Second
+
+// pingSuccess sends a GET to a job's pingOnSuccessURL. Failures are logged
+// and do not affect the run's result.
+func (cm *CronManager) pingSuccess(pingURL, jobName, jobID string) {
+	ctx, cancel := context.WithTimeout(cm.runCtx, pingTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pingURL, nil)
+	if err != nil {
+		slog.Warn("Failed to build success ping", "job", jobName, "id", jobID, "error", err)
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The URL may embed a token, so log only the underlying error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		slog.Warn("Success ping failed", "job", jobName, "id", jobID, "error", err)
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Warn("Success ping rejected", "job", jobName, "id", jobID, "status", resp.StatusCode)
+		return
+	}
+	slog.Debug("Success ping sent", "job", jobName, "id", jobID)
+}
+
+// disableLocked turns a job off and removes its cron entries. Must be called
+// with cm.mu held for writing.
+func (cm *CronManager) disableLocked(job *Job) {
+	cm.unscheduleEntriesLocked(job)
+	job.Enabled = false
+	job.NextRun = nil
+	cm.markChanged(job)
+}
+
+// completeLocked disables a job that has finished its run allowance or
+// window, marking it completed. Must be called with cm.mu held for writing.
+func (cm *CronManager) completeLocked(job *Job) {
+	job.Status = StatusCompleted
+	cm.disableLocked(job)
+}
+
+// persistDisabled saves a job the manager disabled on its own right away, so
+// a restart before the next sync doesn't reschedule it.
+func (cm *CronManager) persistDisabled(jobName, jobID string) {
+	if err := cm.SaveAllJobsToDB(cm.config.DBPath); err != nil {
+		slog.Warn("Failed to persist disabled job", "job", jobName, "id", jobID, "error", err)
+	}
+}
+
+// DeleteJob soft-deletes a job: it is unscheduled and hidden from listings
+// but kept, with its run history, so RestoreJob can bring it back until the
+// retention window passes.
+func (cm *CronManager) DeleteJob(jobID string) error {
+	cm.mu.Lock()
+	job, exists := cm.jobs[jobID]
+	if !exists {
+		cm.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+
+	cm.unscheduleEntriesLocked(job)
+	delete(cm.jobs, jobID)
+	now := time.Now()
+	job.DeletedAt = &now
+	job.dirty = true
+	job.modifiedAt = now
+	cm.deleted[jobID] = job
+	cm.revision++
+	removed := *job
+	cm.mu.Unlock()
+
+	if err := cm.SaveAllJobsToDB(cm.config.DBPath); err != nil {
+		slog.Warn("Failed to persist deleted job", "id", jobID, "error", err)
+	}
+	cm.notify(func(o JobObserver) { o.OnJobRemoved(removed) })
+	return nil
+}
+
+// RestoreJob brings back a soft-deleted job, rescheduling it if it was
+// enabled. Jobs deleted longer ago than the retention window are gone.
+func (cm *CronManager) RestoreJob(jobID string) (*Job, error) {
+	cm.mu.Lock()
+	job, exists := cm.deleted[jobID]
+	if !exists || time.Since(*job.DeletedAt) > cm.config.DeleteRetention {
+		cm.mu.Unlock()
+		return nil, fmt.Errorf("%w: no deleted job %s", ErrJobNotFound, jobID)
+	}
+	delete(cm.deleted, jobID)
+	deletedAt := job.DeletedAt
+	job.DeletedAt = nil
+	cm.mu.Unlock()
+
+	if err := cm.addJob(job, true, true); err != nil {
+		cm.mu.Lock()
+		job.DeletedAt = deletedAt
+		cm.deleted[jobID] = job
+		cm.mu.Unlock()
+		return nil, err
+	}
+
+	if err := cm.SaveAllJobsToDB(cm.config.DBPath); err != nil {
+		slog.Warn("Failed to persist restored job", "id", jobID, "error", err)
+	}
+	restored := cm.jobSnapshot(job)
+	cm.notify(func(o JobObserver) { o.OnJobAdded(restored) })
+	return cm.GetJob(jobID)
+}
+
+// StartDeletedJobPurger starts a goroutine that every interval permanently
+// removes jobs soft-deleted longer ago than the retention window.
+func (cm *CronManager) StartDeletedJobPurger(interval time.Duration) {
+	cm.purger.start(interval, func(context.Context) {
+		cm.purgeDeletedJobs(time.Now().Add(-cm.config.DeleteRetention))
+	})
+}
+
+// purgeDeletedJobs queues soft-deleted jobs deleted before cutoff for
+// removal and flushes them to the database.
+func (cm *CronManager) purgeDeletedJobs(cutoff time.Time) {
+	cm.mu.Lock()
+	var purged int
+	for id, job := range cm.deleted {
+		if job.DeletedAt.Before(cutoff) {
+			delete(cm.deleted, id)
+			cm.removed[id] = struct{}{}
+			purged++
+		}
+	}
+	cm.mu.Unlock()
+
+	if purged == 0 {
+		return
+	}
+	if err := cm.SaveAllJobsToDB(cm.config.DBPath); err != nil {
+		slog.Warn("Failed to purge deleted jobs", "count", purged, "error", err)
+		return
+	}
+	slog.Info("Purged deleted jobs", "count", purged)
+}
+
+// RemoveJob unschedules a job and permanently deletes it from memory and the
+// database. Soft-deleted jobs can be removed this way too.
+func (cm *CronManager) RemoveJob(jobID string) error {
+	removed, err := cm.unscheduleJob(jobID)
+	if err != nil {
+		return err
+	}
+
+	// Delete the row right away so a restart before the next sync can't
+	// resurrect the job. On failure the pending removal is retried by sync.
+	if err := cm.deleteJobRow(jobID); err != nil {
+		slog.Warn("Failed to delete job from database", "id", jobID, "error", err)
+	}
+	// Observers already heard about soft-deleted jobs when they were deleted
+	if removed != nil {
+		cm.notify(func(o JobObserver) { o.OnJobRemoved(*removed) })
+	}
+	return nil
+}
+
+// ToggleState is a job's enabled state after ToggleJob
+type ToggleState struct {
+	ID      string     `json:"id"`
+	Enabled bool       `json:"enabled"`
+	NextRun *time.Time `json:"nextRun,omitempty"`
+	Version int        `json:"version"`
+}
+
+// ToggleJob flips a job between enabled and disabled under a single lock,
+// rescheduling or unscheduling it, and saves the change.
+func (cm *CronManager) ToggleJob(jobID string) (ToggleState, error) {
+	cm.mu.Lock()
+	job, exists := cm.jobs[jobID]
+	if !exists {
+		cm.mu.Unlock()
+		return ToggleState{}, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+
+	if job.Enabled {
+		cm.disableLocked(job)
+	} else {
+		if _, ok := cm.executors[job.Type]; !ok {
+			cm.mu.Unlock()
+			return ToggleState{}, fmt.Errorf("%w: %s", ErrUnknownJobType, job.Type)
+		}
+		if job.LoadError != "" {
+			cm.mu.Unlock()
+			return ToggleState{}, fmt.Errorf("%w: %s; update the job to fix it", ErrInvalidConfig, job.LoadError)
+		}
+		if err := cm.scheduleLocked(job); err != nil {
+			cm.mu.Unlock()
+			return ToggleState{}, err
+		}
+		job.Enabled = true
+	}
+	now := time.Now()
+	job.Version++
+	job.UpdatedAt = &now
+	cm.markChanged(job)
+	state := ToggleState{ID: job.ID, Enabled: job.Enabled, NextRun: job.NextRun, Version: job.Version}
+	cm.mu.Unlock()
+
+	if err := cm.SaveAllJobsToDB(cm.config.DBPath); err != nil {
+		slog.Warn("Failed to persist toggled job", "id", jobID, "error", err)
+	}
+	return state, nil
+}
+
+// RenameJob changes only a job's name, leaving its schedule, cron entries
+// and run history untouched. A non-zero version must match the current one.
+// It returns a copy of the renamed job.
+func (cm *CronManager) RenameJob(jobID, name string, version int) (*Job, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name cannot be empty", ErrInvalidConfig)
+	}
+
+	cm.mu.Lock()
+	job, exists := cm.jobs[jobID]
+	if !exists {
+		cm.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	if version != 0 && version != job.Version {
+		cm.mu.Unlock()
+		return nil, fmt.Errorf("%w: have version %d, current is %d", ErrVersionConflict, version, job.Version)
+	}
+	if err := cm.checkNameLocked(jobID, name); err != nil {
+		cm.mu.Unlock()
+		return nil, err
+	}
+	now := time.Now()
+	job.Name = name
+	job.Version++
+	job.UpdatedAt = &now
+	cm.markChanged(job)
+	renamed := *job
+	cm.mu.Unlock()
+
+	if err := cm.SaveAllJobsToDB(cm.config.DBPath); err != nil {
+		slog.Warn("Failed to persist renamed job", "id", jobID, "error", err)
+	}
+	return &renamed, nil
+}
+
+// RemoveAllJobs unschedules and deletes every job, returning how many were
+// removed.
+func (cm *CronManager) RemoveAllJobs() (int, error) {
+	cm.mu.Lock()
+	count := len(cm.jobs)
+	removed := make([]Job, 0, count)
+	for _, job := range cm.jobs {
+		removed = append(removed, *job)
+		cm.unscheduleLocked(job)
+	}
+	cm.mu.Unlock()
+	for _, job := range removed {
+		cm.notify(func(o JobObserver) { o.OnJobRemoved(job) })
+	}
+
+	// Flush the queued removals now rather than waiting for the next sync
+	if err := cm.SaveAllJobsToDB(cm.config.DBPath); err != nil {
+		return count, fmt.Errorf("jobs removed from the scheduler but not the database: %w", err)
+	}
+	return count, nil
+}
+
+// unscheduleJob removes a job from the scheduler and the in-memory map and
+// queues its row for deletion on the next sync. It returns a copy of the
+// removed job, or nil if it had been soft-deleted.
+func (cm *CronManager) unscheduleJob(jobID string) (*Job, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, exists := cm.deleted[jobID]; exists {
+		delete(cm.deleted, jobID)
+		cm.removed[jobID] = struct{}{}
+		return nil, nil
+	}
+
+	job, exists := cm.jobs[jobID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+
+	cm.unscheduleLocked(job)
+	removed := *job
+	return &removed, nil
+}
+
+// unscheduleLocked does the work of unscheduleJob. Must be called with cm.mu
+// held for writing.
+func (cm *CronManager) unscheduleLocked(job *Job) {
+	cm.unscheduleEntriesLocked(job)
+
+	delete(cm.jobs, job.ID)
+	cm.removed[job.ID] = struct{}{}
+	cm.revision++
+}
+
+// markChanged flags a job for the next database save and bumps the revision
+// counter. Must be called with cm.mu held for writing.
+func (cm *CronManager) markChanged(job *Job) {
+	job.dirty = true
+	job.modifiedAt = time.Now()
+	job.Status = jobStatus(job, job.modifiedAt)
+	cm.revision++
+}
+
+// Revision returns a counter that increases whenever any job is added,
+// changed or removed.
+func (cm *CronManager) Revision() uint64 {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.revision
+}
+
+// UpdateJob replaces job jobID with updatedJob, which must carry the
+// current version. The update is validated as fully as AddJob validates a
+// new job, and a rejected update leaves the existing job as it was.
+func (cm *CronManager) UpdateJob(jobID string, updatedJob *Job) error {
+	updatedJob.ID = jobID
+	if err := applyInterval(updatedJob); err != nil {
+		return err
+	}
+	if err := cm.checkScheduleHorizons(updatedJob); err != nil {
+		return err
+	}
+
+	// The job is replaced under one lock, so two concurrent updates of the
+	// same version can't both win, an invalid update is rejected before the
+	// existing job is touched, and no sync or reader ever finds the job
+	// missing.
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	existing, exists := cm.jobs[jobID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	if updatedJob.Version != existing.Version {
+		return fmt.Errorf("%w: have version %d, current is %d", ErrVersionConflict, updatedJob.Version, existing.Version)
+	}
+	if err := cm.checkNameLocked(jobID, updatedJob.Name); err != nil {
+		return err
+	}
+	if err := cm.validateJobLocked(updatedJob); err != nil {
+		return err
+	}
+
+	// Creation metadata and run state always survive the update
+	now := time.Now()
+	updatedJob.Version = existing.Version + 1
+	updatedJob.CreatedBy = existing.CreatedBy
+	updatedJob.CreatedAt = existing.CreatedAt
+	updatedJob.UpdatedAt = &now
+	updatedJob.Status = ""
+	carryRunState(updatedJob, existing)
+
+	// An enabled job whose schedule is unchanged keeps its cron entries, and
+	// so its next run, rather than being rescheduled
+	keepEntries := existing.Enabled && updatedJob.Enabled && cm.sameScheduling(existing, updatedJob)
+	if keepEntries {
+		updatedJob.CronEntryIDs = existing.CronEntryIDs
+		updatedJob.NextRun = existing.NextRun
+	} else {
+		updatedJob.CronEntryIDs = nil
+	}
+	// The existing job keeps its entries until its replacement is in, so a
+	// rejected update leaves it exactly as it was
+	if err := cm.addJobLocked(updatedJob, false, false); err != nil {
+		return err
+	}
+	if !keepEntries {
+		cm.unscheduleEntriesLocked(existing)
+	}
+	return nil
+}
+
+// carryRunState copies the run history fields of existing onto updated,
+// since only runs change them
+func carryRunState(updated, existing *Job) {
+	updated.LastRun = existing.LastRun
+	updated.LastError = existing.LastError
+	updated.LastErrorAt = existing.LastErrorAt
+	updated.LastDriftMs = existing.LastDriftMs
+	updated.SuccessCount = existing.SuccessCount
+	updated.FailureCount = existing.FailureCount
+}
+
+// SchedulerEntry is one cron scheduler entry belonging to a job
+type SchedulerEntry struct {
+	EntryID    int        `json:"entryId"`
+	Registered bool       `json:"registered"`
+	Prev       *time.Time `json:"prev,omitempty"`
+	Next       *time.Time `json:"next,omitempty"`
+}
+
+// SchedulerState describes a job's entries in the underlying cron scheduler
+type SchedulerState struct {
+	JobID   string           `json:"jobId"`
+	Enabled bool             `json:"enabled"`
+	Entries []SchedulerEntry `json:"entries"`
+}
+
+// GetSchedulerState reports whether each of a job's cron entries is live and
+// the entries' previous and next fire times.
+func (cm *CronManager) GetSchedulerState(jobID string) (SchedulerState, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	job, exists := cm.jobs[jobID]
+	if !exists {
+		return SchedulerState{}, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+
+	state := SchedulerState{JobID: jobID, Enabled: job.Enabled, Entries: []SchedulerEntry{}}
+	for _, id := range job.CronEntryIDs {
+		entry := cm.cron.Entry(id)
+		se := SchedulerEntry{EntryID: int(id), Registered: entry.Valid()}
+		if !entry.Prev.IsZero() {
+			prev := entry.Prev
+			se.Prev = &prev
+		}
+		if !entry.Next.IsZero() {
+			next := entry.Next
+			se.Next = &next
+		}
+		state.Entries = append(state.Entries, se)
+	}
+	return state, nil
+}
+
+// MergedConfig returns a copy of a job's config with patch applied on top.
+// Keys set to nil in patch are removed. The job itself is not modified.
+func (cm *CronManager) MergedConfig(jobID string, patch map[string]any) (map[string]any, error) {
+	cm.mu.RLock()
+	job, exists := cm.jobs[jobID]
+	if !exists {
+		cm.mu.RUnlock()
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	merged := maps.Clone(job.Config)
+	cm.mu.RUnlock()
+
+	if merged == nil {
+		merged = make(map[string]any, len(patch))
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged, nil
+}
+
+// GetJob returns a copy of a job, taken under the lock, so callers can read
+// it while runs update the job.
+func (cm *CronManager) GetJob(jobID string) (*Job, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	job, exists := cm.jobs[jobID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// getJobModified returns a copy of a job along with when it last changed
+func (cm *CronManager) getJobModified(jobID string) (*Job, time.Time, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	job, exists := cm.jobs[jobID]
+	if !exists {
+		return nil, time.Time{}, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	snapshot := *job
+	return &snapshot, job.modifiedAt, nil
+}
+
+// CloneJob creates a disabled copy of an existing job with a fresh ID. The
+// description, type, schedule, interval, config and tags are copied and the
+// name gets a "(copy)" suffix.
+func (cm *CronManager) CloneJob(jobID, createdBy string) (*Job, error) {
+	cm.mu.RLock()
+	src, exists := cm.jobs[jobID]
+	if !exists {
+		cm.mu.RUnlock()
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	now := time.Now()
+	clone := &Job{
+		Name:        src.Name + " (copy)",
+		Description: src.Description,
+		Type:        src.Type,
+		Schedule:    slices.Clone(src.Schedule),
+		Interval:    src.Interval,
+		Enabled:     false,
+		Config:      maps.Clone(src.Config),
+		Tags:        slices.Clone(src.Tags),
+		CreatedBy:   createdBy,
+		CreatedAt:   &now,
+		UpdatedAt:   &now,
+	}
+	cm.mu.RUnlock()
+
+	clone.ID = cm.generateUniqueJobID()
+	if err := cm.AddJob(clone); err != nil {
+		return nil, err
+	}
+	added := cm.jobSnapshot(clone)
+	return &added, nil
+}
+
+// GetAllJobs returns copies of every job, most recently run first
+func (cm *CronManager) GetAllJobs() []*Job {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
 
 	jobs := make([]*Job, 0, len(cm.jobs))
 
 	// Order jobs by last run time (most recent first)
 
 	for _, job := range cm.jobs {
-		jobs = append(jobs, job)
+		snapshot := *job
+		jobs = append(jobs, &snapshot)
 	}
 	sort.Slice(jobs, func(i, j int) bool {
 		a := jobs[i].LastRun
@@ -413,6 +1911,150 @@ func (cm *CronManager) GetAllJobs() []*Job {
 	return jobs
 }
 
+// GetJobsByTags returns the jobs carrying every one of the given tags, in the
+// same order as GetAllJobs. With no tags it returns all jobs.
+func (cm *CronManager) GetJobsByTags(tags []string) []*Job {
+	jobs := cm.GetAllJobs()
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return jobs
+	}
+
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	filtered := make([]*Job, 0, len(jobs))
+	for _, job := range jobs {
+		if hasAllTags(job.Tags, tags) {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered
+}
+
+// SearchJobs returns the jobs carrying all of tags whose name, ID or
+// description contains query, ignoring case. An empty query matches every
+// job.
+func (cm *CronManager) SearchJobs(tags []string, query string) []*Job {
+	jobs := cm.GetJobsByTags(tags)
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return jobs
+	}
+
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	filtered := make([]*Job, 0, len(jobs))
+	for _, job := range jobs {
+		if strings.Contains(strings.ToLower(job.Name), query) ||
+			strings.Contains(strings.ToLower(job.ID), query) ||
+			strings.Contains(strings.ToLower(job.Description), query) {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered
+}
+
+// TagCount is the number of jobs using a tag
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// GetTagCounts returns every tag in use with the number of jobs carrying it,
+// sorted by tag.
+func (cm *CronManager) GetTagCounts() []TagCount {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, job := range cm.jobs {
+		for _, tag := range job.Tags {
+			counts[tag]++
+		}
+	}
+
+	result := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		result = append(result, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Tag < result[j].Tag
+	})
+	return result
+}
+
+// JobSummary holds aggregate counts over all jobs
+type JobSummary struct {
+	Total     int             `json:"total"`
+	ByType    map[JobType]int `json:"byType"`
+	Enabled   int             `json:"enabled"`
+	Disabled  int             `json:"disabled"`
+	Overdue   int             `json:"overdue"`
+	RunsToday int64           `json:"runsToday"`
+}
+
+// GetJobSummary counts jobs by type, state and overdue status as of now,
+// along with the number of runs started since local midnight.
+func (cm *CronManager) GetJobSummary(now time.Time) (JobSummary, error) {
+	summary := JobSummary{ByType: make(map[JobType]int)}
+
+	cm.mu.RLock()
+	summary.Total = len(cm.jobs)
+	for _, job := range cm.jobs {
+		summary.ByType[job.Type]++
+		if job.Enabled {
+			summary.Enabled++
+		} else {
+			summary.Disabled++
+		}
+		if jobOverdue(job, now) {
+			summary.Overdue++
+		}
+	}
+	cm.mu.RUnlock()
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	runs, err := cm.countRunsSince(midnight)
+	if err != nil {
+		return summary, err
+	}
+	summary.RunsToday = runs
+	return summary, nil
+}
+
+// normalizeTags trims whitespace and drops empty and duplicate tags,
+// preserving first-seen order.
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func hasAllTags(have, want []string) bool {
+	for _, w := range want {
+		if !slices.Contains(have, w) {
+			return false
+		}
+	}
+	return true
+}
+
 // generateUniqueJobID generates a UUID and checks it against existing jobs to avoid conflicts
 func (cm *CronManager) generateUniqueJobID() string {
 	cm.mu.RLock()
@@ -421,7 +2063,9 @@ func (cm *CronManager) generateUniqueJobID() string {
 	maxAttempts := 100 // Prevent infinite loop in the extremely unlikely case of collisions
 	for range maxAttempts {
 		id := uuid.New().String()
-		if _, exists := cm.jobs[id]; !exists {
+		_, active := cm.jobs[id]
+		_, deleted := cm.deleted[id]
+		if !active && !deleted {
 			return id
 		}
 	}
@@ -0,0 +1,71 @@
+package cronmgr
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// lateRejectExecutor accepts the first allowed configs it validates and
+// rejects every one after, to reject a job past UpdateJob's own checks
+type lateRejectExecutor struct {
+	allowed int
+	calls   int
+}
+
+var errLateReject = errors.New("rejected late")
+
+func (e *lateRejectExecutor) Execute(map[string]any) error { return nil }
+
+func (e *lateRejectExecutor) Validate(map[string]any) error {
+	e.calls++
+	if e.calls > e.allowed {
+		return errLateReject
+	}
+	return nil
+}
+
+func TestRejectedUpdateLeavesJobScheduled(t *testing.T) {
+	const late JobType = "late"
+	cm := newTestManager(t, filepath.Join(t.TempDir(), "jobs.db"))
+	// One validation for AddJob and one for UpdateJob's checks; the one made
+	// while adding the replacement fails
+	if err := cm.RegisterExecutor(late, &lateRejectExecutor{allowed: 2}); err != nil {
+		t.Fatalf("RegisterExecutor: %v", err)
+	}
+	job := &Job{ID: "job", Name: "job", Type: late, Schedule: ScheduleList{"0 0 * * * *"}, Enabled: true, Config: map[string]any{"n": 1}}
+	if err := cm.AddJob(job); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	nextRun := time.Now().Add(time.Hour).Truncate(time.Second)
+	cm.mu.Lock()
+	job.NextRun = &nextRun
+	cm.mu.Unlock()
+	before, _ := cm.GetJob("job")
+
+	update := &Job{Name: "job", Type: late, Schedule: ScheduleList{"0 30 * * * *"}, Enabled: true, Config: map[string]any{"n": 2}, Version: before.Version}
+	if err := cm.UpdateJob("job", update); !errors.Is(err, errLateReject) {
+		t.Fatalf("UpdateJob error = %v, want %v", err, errLateReject)
+	}
+
+	after, err := cm.GetJob("job")
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if !slices.Equal(after.CronEntryIDs, before.CronEntryIDs) || len(cm.cron.Entries()) != len(before.CronEntryIDs) {
+		t.Errorf("cron entries = %v with %d scheduled, want %v", after.CronEntryIDs, len(cm.cron.Entries()), before.CronEntryIDs)
+	}
+	for _, id := range after.CronEntryIDs {
+		if !cm.cron.Entry(id).Valid() {
+			t.Errorf("cron entry %d is no longer scheduled", id)
+		}
+	}
+	if after.NextRun == nil || !after.NextRun.Equal(nextRun) {
+		t.Errorf("next run = %v, want %v", after.NextRun, nextRun)
+	}
+	if after.Version != before.Version || after.Config["n"] != 1 || !slices.Equal(after.Schedule, before.Schedule) {
+		t.Errorf("job changed to version %d, config %v, schedule %v", after.Version, after.Config, after.Schedule)
+	}
+}
@@ -0,0 +1,96 @@
+package cronmgr
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// driftBuckets are the upper bounds, in seconds, of the scheduler drift
+// histogram
+var driftBuckets = [...]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// driftHistogram counts how late the scheduler fires jobs. The zero value
+// is ready to use.
+type driftHistogram struct {
+	mu     sync.Mutex
+	counts [len(driftBuckets)]uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func (h *driftHistogram) observe(d time.Duration) {
+	v := d.Seconds()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range driftBuckets {
+		if v <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// writeTo writes the histogram in the Prometheus text format
+func (h *driftHistogram) writeTo(w io.Writer, name, help string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	var cumulative uint64
+	for i, bound := range driftBuckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", name, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+}
+
+// runScheduled runs a job fired by the scheduler, first recording how long
+// after its scheduled time the scheduler fired it
+func (cm *CronManager) runScheduled(jobID string) {
+	started := time.Now()
+	var scheduled time.Time
+	cm.mu.Lock()
+	if job, exists := cm.jobs[jobID]; exists {
+		if scheduled = cm.firedAtLocked(job, started); !scheduled.IsZero() {
+			drift := started.Sub(scheduled)
+			ms := drift.Milliseconds()
+			job.LastDriftMs = &ms
+			cm.drift.observe(drift)
+		}
+	}
+	cm.mu.Unlock()
+
+	// Instances agree on a firing by its scheduled time; if it isn't known
+	// the second it fired in is the best shared guess
+	if scheduled.IsZero() {
+		scheduled = started.Truncate(time.Second)
+	}
+	cm.executeJob(jobID, scheduled)
+}
+
+// firedAtLocked returns the scheduled time of the job's most recent firing
+// at or before now, or the zero time if none is known. Must be called with
+// cm.mu held.
+func (cm *CronManager) firedAtLocked(job *Job, now time.Time) time.Time {
+	var fired time.Time
+	for _, id := range job.CronEntryIDs {
+		prev := cm.cron.Entry(id).Prev
+		if !prev.After(now) && prev.After(fired) {
+			fired = prev
+		}
+	}
+	return fired
+}
+
+// HandleMetrics serves scheduler metrics in the Prometheus text format
+func (cm *CronManager) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	cm.drift.writeTo(w, "chronos_schedule_drift_seconds", "Delay between a job's scheduled fire time and its start.")
+}
@@ -0,0 +1,69 @@
+package cronmgr
+
+import "time"
+
+// JobObserver is notified of job lifecycle events, so an embedding
+// application can add metrics or auditing. Each method gets a copy of the
+// job as it was at the event; its Config, Schedule and Tags are shared with
+// the manager and must not be modified.
+//
+// Observers are called synchronously, never with the manager's lock held,
+// so they may call back into the manager but should return quickly. Embed
+// NopJobObserver to implement only some of the methods.
+type JobObserver interface {
+	// OnJobStart is called as a run begins executing
+	OnJobStart(job Job)
+	// OnJobSuccess and OnJobFailure are called once a run has finished and
+	// been recorded
+	OnJobSuccess(job Job, duration time.Duration)
+	OnJobFailure(job Job, duration time.Duration, err error)
+	// OnJobAdded is called after AddJob or RestoreJob adds a job
+	OnJobAdded(job Job)
+	// OnJobRemoved is called after a job is deleted or removed
+	OnJobRemoved(job Job)
+}
+
+// NopJobObserver implements JobObserver with methods that do nothing
+type NopJobObserver struct{}
+
+func (NopJobObserver) OnJobStart(Job)                         {}
+func (NopJobObserver) OnJobSuccess(Job, time.Duration)        {}
+func (NopJobObserver) OnJobFailure(Job, time.Duration, error) {}
+func (NopJobObserver) OnJobAdded(Job)                         {}
+func (NopJobObserver) OnJobRemoved(Job)                       {}
+
+// AddObserver registers o to be notified of job lifecycle events
+func (cm *CronManager) AddObserver(o JobObserver) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.observers = append(cm.observers, o)
+}
+
+// notify calls fn with each registered observer. It must be called without
+// cm.mu held.
+func (cm *CronManager) notify(fn func(JobObserver)) {
+	cm.mu.RLock()
+	observers := cm.observers
+	cm.mu.RUnlock()
+	for _, o := range observers {
+		fn(o)
+	}
+}
+
+// notifyRunEnd tells observers how a run of job ended
+func (cm *CronManager) notifyRunEnd(job Job, duration time.Duration, err error) {
+	cm.notify(func(o JobObserver) {
+		if err != nil {
+			o.OnJobFailure(job, duration, err)
+		} else {
+			o.OnJobSuccess(job, duration)
+		}
+	})
+}
+
+// jobSnapshot copies a job for observers under the read lock
+func (cm *CronManager) jobSnapshot(job *Job) Job {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return *job
+}
@@ -0,0 +1,309 @@
+package cronmgr
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+
+	"tapasrm.dev/cron-ui/storage"
+)
+
+// Config keys understood by the manager for every job type, alongside the
+// executor-specific keys each JobExecutor validates.
+const (
+	// optJitter delays each run by a random duration up to this Go duration
+	optJitter = "jitter"
+	// optMaxRuns disables the job once it has run this many times
+	optMaxRuns = "maxRuns"
+	// optNotBefore and optNotAfter bound the RFC3339 window a job may run in
+	optNotBefore = "notBefore"
+	optNotAfter  = "notAfter"
+	// optDependsOn lists job IDs that must have succeeded within
+	// optDependsOnWindow (default defaultDependsOnWindow) before a run
+	optDependsOn       = "dependsOn"
+	optDependsOnWindow = "dependsOnWindow"
+	// optPingOnSuccessURL is requested after every successful run
+	optPingOnSuccessURL = "pingOnSuccessURL"
+	// optRunOnStart runs an enabled job once when the manager starts
+	optRunOnStart = "runOnStart"
+	// optMisfire is the misfire policy for runs missed while the server was
+	// down: misfireSkip (the default) or misfireRunOnce
+	optMisfire = "misfire"
+	// optTimezone and optUseSeconds override the instance-wide time zone and
+	// seconds field setting for a job's schedules
+	optTimezone   = "timezone"
+	optUseSeconds = "useSeconds"
+	// optUploadOutputTo is a storage folder each run's captured output is
+	// uploaded to, for job types that capture output
+	optUploadOutputTo = "uploadOutputTo"
+)
+
+// Misfire policies
+const (
+	misfireSkip    = "skip"
+	misfireRunOnce = "runOnce"
+)
+
+// defaultDependsOnWindow is how recent a dependency's success must be
+const defaultDependsOnWindow = 24 * time.Hour
+
+// validateJobOptions checks the manager-level config keys shared by all job
+// types.
+func validateJobOptions(config map[string]any) error {
+	if _, err := jobJitter(config); err != nil {
+		return err
+	}
+	if _, err := jobMaxRuns(config); err != nil {
+		return err
+	}
+	if _, _, err := jobWindow(config); err != nil {
+		return err
+	}
+	if _, err := jobDependsOn(config); err != nil {
+		return err
+	}
+	if _, err := jobDependsOnWindow(config); err != nil {
+		return err
+	}
+	if _, err := jobPingOnSuccessURL(config); err != nil {
+		return err
+	}
+	if _, err := jobRunOnStart(config); err != nil {
+		return err
+	}
+	if _, err := jobMisfire(config); err != nil {
+		return err
+	}
+	if _, err := jobTimezone(config); err != nil {
+		return err
+	}
+	if _, err := boolOption(config, optUseSeconds); err != nil {
+		return err
+	}
+	if _, err := jobUploadOutputTo(config); err != nil {
+		return err
+	}
+	return nil
+}
+
+// jobJitter returns the maximum random start delay configured for a job
+func jobJitter(config map[string]any) (time.Duration, error) {
+	return durationOption(config, optJitter)
+}
+
+// jobMaxRuns returns the number of runs after which a job disables itself,
+// or 0 for no limit.
+func jobMaxRuns(config map[string]any) (int64, error) {
+	n, err := intOption(config, optMaxRuns)
+	if err == nil && config[optMaxRuns] != nil && n == 0 {
+		return 0, fmt.Errorf("'%s' must be at least 1", optMaxRuns)
+	}
+	return n, err
+}
+
+// jobWindow returns the optional start and end of the period a job may run
+// in. A zero time means that side is unbounded.
+func jobWindow(config map[string]any) (notBefore, notAfter time.Time, err error) {
+	if notBefore, err = timeOption(config, optNotBefore); err != nil {
+		return
+	}
+	if notAfter, err = timeOption(config, optNotAfter); err != nil {
+		return
+	}
+	if !notBefore.IsZero() && !notAfter.IsZero() && !notAfter.After(notBefore) {
+		err = fmt.Errorf("'%s' must be after '%s'", optNotAfter, optNotBefore)
+	}
+	return
+}
+
+// jobDependsOn returns the IDs of the jobs a job depends on
+func jobDependsOn(config map[string]any) ([]string, error) {
+	return stringListOption(config, optDependsOn)
+}
+
+// jobDependsOnWindow returns how recently dependencies must have succeeded
+func jobDependsOnWindow(config map[string]any) (time.Duration, error) {
+	d, err := durationOption(config, optDependsOnWindow)
+	if err == nil && d == 0 {
+		d = defaultDependsOnWindow
+	}
+	return d, err
+}
+
+// jobPingOnSuccessURL returns the URL to ping after a successful run, or ""
+func jobPingOnSuccessURL(config map[string]any) (string, error) {
+	raw, ok := config[optPingOnSuccessURL]
+	if !ok || raw == nil {
+		return "", nil
+	}
+	v, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("'%s' must be a string", optPingOnSuccessURL)
+	}
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("'%s' must be an http or https URL", optPingOnSuccessURL)
+	}
+	return v, nil
+}
+
+// jobRunOnStart reports whether a job should run once at startup
+func jobRunOnStart(config map[string]any) (bool, error) {
+	return boolOption(config, optRunOnStart)
+}
+
+// jobMisfire returns a job's misfire policy, misfireSkip by default
+func jobMisfire(config map[string]any) (string, error) {
+	raw, ok := config[optMisfire]
+	if !ok || raw == nil {
+		return misfireSkip, nil
+	}
+	switch v, _ := raw.(string); v {
+	case misfireSkip, misfireRunOnce:
+		return v, nil
+	}
+	return "", fmt.Errorf("'%s' must be %q or %q", optMisfire, misfireSkip, misfireRunOnce)
+}
+
+// jobTimezone returns the time zone a job's schedules override the default
+// with, or nil
+func jobTimezone(config map[string]any) (*time.Location, error) {
+	raw, ok := config[optTimezone]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	v, ok := raw.(string)
+	if !ok || v == "" {
+		return nil, fmt.Errorf("'%s' must be an IANA time zone name like \"Europe/Berlin\"", optTimezone)
+	}
+	loc, err := time.LoadLocation(v)
+	if err != nil {
+		return nil, fmt.Errorf("'%s': unknown time zone %q", optTimezone, v)
+	}
+	return loc, nil
+}
+
+// jobUploadOutputTo returns the cleaned storage folder to upload run output
+// to, or "" if output isn't uploaded
+func jobUploadOutputTo(config map[string]any) (string, error) {
+	raw, ok := config[optUploadOutputTo]
+	if !ok || raw == nil {
+		return "", nil
+	}
+	v, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("'%s' must be a folder name", optUploadOutputTo)
+	}
+	folder, err := storage.CleanFolder(v)
+	if err != nil {
+		return "", fmt.Errorf("'%s': %w", optUploadOutputTo, err)
+	}
+	return folder, nil
+}
+
+// boolOption parses an optional boolean
+func boolOption(config map[string]any, key string) (bool, error) {
+	raw, ok := config[key]
+	if !ok || raw == nil {
+		return false, nil
+	}
+	v, ok := raw.(bool)
+	if !ok {
+		return false, fmt.Errorf("'%s' must be true or false", key)
+	}
+	return v, nil
+}
+
+// durationOption parses an optional non-negative Go duration string
+func durationOption(config map[string]any, key string) (time.Duration, error) {
+	raw, ok := config[key]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+	v, ok := raw.(string)
+	if !ok {
+		return 0, fmt.Errorf("'%s' must be a duration string like \"30s\"", key)
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("'%s': %w", key, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("'%s' must not be negative", key)
+	}
+	return d, nil
+}
+
+// intOption parses an optional non-negative whole number. JSON numbers
+// decode as float64, so integral floats are accepted.
+func intOption(config map[string]any, key string) (int64, error) {
+	raw, ok := config[key]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+	var n int64
+	switch v := raw.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("'%s' must be a whole number", key)
+		}
+		n = int64(v)
+	case int:
+		n = int64(v)
+	case int64:
+		n = v
+	default:
+		return 0, fmt.Errorf("'%s' must be a number", key)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("'%s' must not be negative", key)
+	}
+	return n, nil
+}
+
+// timeOption parses an optional RFC3339 timestamp
+func timeOption(config map[string]any, key string) (time.Time, error) {
+	raw, ok := config[key]
+	if !ok || raw == nil {
+		return time.Time{}, nil
+	}
+	v, ok := raw.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("'%s' must be an RFC3339 timestamp", key)
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' must be an RFC3339 timestamp: %w", key, err)
+	}
+	return t, nil
+}
+
+// stringListOption parses an optional list of non-empty strings
+func stringListOption(config map[string]any, key string) ([]string, error) {
+	raw, ok := config[key]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	var items []any
+	switch v := raw.(type) {
+	case []any:
+		items = v
+	case []string:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	default:
+		return nil, fmt.Errorf("'%s' must be a list of strings", key)
+	}
+
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok || str == "" {
+			return nil, fmt.Errorf("'%s' must be a list of non-empty strings", key)
+		}
+		out = append(out, str)
+	}
+	return out, nil
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -15,21 +17,65 @@ import (
 //   id TEXT PRIMARY KEY,
 //   name TEXT,
 //   type TEXT,
-//   schedule TEXT,
+//   schedule TEXT,          -- expression, or JSON array for several
 //   schedule_desc TEXT,
 //   enabled INTEGER,
 //   config_json TEXT,
 //   last_run INTEGER,
-//   next_run INTEGER
+//   next_run INTEGER,
+//   version INTEGER NOT NULL DEFAULT 0,
+//   tags_json TEXT,
+//   created_by TEXT,
+//   created_at INTEGER,
+//   updated_at INTEGER,
+//   last_error TEXT,
+//   last_error_at INTEGER,
+//   success_count INTEGER NOT NULL DEFAULT 0,
+//   failure_count INTEGER NOT NULL DEFAULT 0,
+//   deleted_at INTEGER,      -- set while a job is soft-deleted
+//   status TEXT,
+//   description TEXT,
+//   interval TEXT           -- set when the schedule was given as an interval
 // );
 
-func openDB(path string) (*sql.DB, error) {
+// Pragma values accepted for the jobs database. They are interpolated into
+// the connection string, so anything else is rejected.
+var (
+	sqliteJournalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+	sqliteSynchronous  = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+)
+
+// sqliteDSN builds the connection string used for the jobs database. The
+// busy timeout makes concurrent writers wait instead of failing with
+// "database is locked".
+func sqliteDSN(path, journalMode, synchronous string) (string, error) {
+	journalMode = strings.ToUpper(journalMode)
+	if !slices.Contains(sqliteJournalModes, journalMode) {
+		return "", fmt.Errorf("invalid SQLite journal mode %q, want one of %s", journalMode, strings.Join(sqliteJournalModes, ", "))
+	}
+	synchronous = strings.ToUpper(synchronous)
+	if !slices.Contains(sqliteSynchronous, synchronous) {
+		return "", fmt.Errorf("invalid SQLite synchronous setting %q, want one of %s", synchronous, strings.Join(sqliteSynchronous, ", "))
+	}
+	return fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=%s&_synchronous=%s", path, journalMode, synchronous), nil
+}
+
+func openDB(path, journalMode, synchronous string) (*sql.DB, error) {
+	dsn, err := sqliteDSN(path, journalMode, synchronous)
+	if err != nil {
+		return nil, err
+	}
 	// github.com/mattn/go-sqlite3 registers the driver name "sqlite3"
-	db, err := sql.Open("sqlite3", path)
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, err
 	}
-	// Create table if not exists
+	// SQLite serializes writers anyway; a small pool keeps reads concurrent
+	// under WAL without churning file handles.
+	db.SetMaxOpenConns(4)
+	db.SetMaxIdleConns(2)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+	// Create table if not exists (runs once per handle)
 	schema := `CREATE TABLE IF NOT EXISTS jobs (
         id TEXT PRIMARY KEY,
         name TEXT,
@@ -45,59 +91,293 @@ func openDB(path string) (*sql.DB, error) {
 		db.Close()
 		return nil, err
 	}
+	if err := migrateColumns(db, "jobs", addedColumns); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec(jobRunsSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := migrateColumns(db, "job_runs", addedRunColumns); err != nil {
+		db.Close()
+		return nil, err
+	}
 	return db, nil
 }
 
-// SaveAllJobsToDB writes all jobs currently in memory to the SQLite DB (upsert semantics)
-func (cm *CronManager) SaveAllJobsToDB(path string) error {
-	db, err := openDB(path)
+// setNameIndex adds a unique index on the names of jobs that aren't
+// soft-deleted, or drops it when names need not be unique.
+func setNameIndex(db *sql.DB, unique bool) error {
+	if !unique {
+		_, err := db.Exec("DROP INDEX IF EXISTS idx_jobs_name")
+		return err
+	}
+	_, err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_name ON jobs(name) WHERE deleted_at IS NULL")
+	return err
+}
+
+// column is a column added to a table after its original schema
+type column struct {
+	name string
+	decl string
+}
+
+// addedColumns lists columns introduced after the original jobs schema.
+// migrateColumns adds any that are missing so older database files keep
+// loading.
+var addedColumns = []column{
+	{"version", "INTEGER NOT NULL DEFAULT 0"},
+	{"tags_json", "TEXT"},
+	{"created_by", "TEXT"},
+	{"created_at", "INTEGER"},
+	{"updated_at", "INTEGER"},
+	{"last_error", "TEXT"},
+	{"last_error_at", "INTEGER"},
+	{"success_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"failure_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"deleted_at", "INTEGER"},
+	{"status", "TEXT"},
+	{"description", "TEXT"},
+	{"interval", "TEXT"},
+}
+
+func migrateColumns(db *sql.DB, table string, columns []column) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
 	if err != nil {
 		return err
 	}
-	defer db.Close()
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-	tx, err := db.Begin()
+	for _, col := range columns {
+		if existing[col.name] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, col.name, col.decl)); err != nil {
+			return fmt.Errorf("add column %s.%s: %w", table, col.name, err)
+		}
+	}
+	return nil
+}
+
+// database returns the shared handle for path, opening it on first use. If a
+// different path is requested the previous handle is closed and replaced.
+func (cm *CronManager) database(path string) (*sql.DB, error) {
+	cm.dbMu.Lock()
+	defer cm.dbMu.Unlock()
+
+	if cm.db != nil && cm.dbPath == path {
+		return cm.db, nil
+	}
+
+	db, err := openDB(path, cm.config.SQLiteJournalMode, cm.config.SQLiteSynchronous)
 	if err != nil {
+		return nil, err
+	}
+	if err := setNameIndex(db, cm.config.RequireUniqueNames); err != nil {
+		// Names duplicated before uniqueness was required stay loadable;
+		// the manager still rejects new duplicates
+		slog.Warn("Could not create unique job name index", "error", err)
+	}
+	if cm.db != nil {
+		cm.db.Close()
+	}
+	cm.db = db
+	cm.dbPath = path
+	return db, nil
+}
+
+// closeDB closes the shared database handle if one is open.
+func (cm *CronManager) closeDB() error {
+	cm.dbMu.Lock()
+	defer cm.dbMu.Unlock()
+
+	if cm.db == nil {
+		return nil
+	}
+	err := cm.db.Close()
+	cm.db = nil
+	cm.dbPath = ""
+	return err
+}
+
+// currentDB returns the open database handle, or nil if none is open.
+func (cm *CronManager) currentDB() *sql.DB {
+	cm.dbMu.Lock()
+	defer cm.dbMu.Unlock()
+	return cm.db
+}
+
+// deleteJobRow removes a job's row from the open database, if any, and clears
+// its pending removal.
+func (cm *CronManager) deleteJobRow(jobID string) error {
+	db := cm.currentDB()
+	if db == nil {
+		return nil
+	}
+
+	if _, err := db.Exec(`DELETE FROM jobs WHERE id = ?`, jobID); err != nil {
 		return err
 	}
-	stmt, err := tx.Prepare(`INSERT INTO jobs(id,name,type,schedule,schedule_desc,enabled,config_json,last_run,next_run)
-        VALUES(?,?,?,?,?,?,?,?,?)
-        ON CONFLICT(id) DO UPDATE SET
-          name=excluded.name,
-          type=excluded.type,
-          schedule=excluded.schedule,
-          schedule_desc=excluded.schedule_desc,
-          enabled=excluded.enabled,
-          config_json=excluded.config_json,
-          last_run=excluded.last_run,
-          next_run=excluded.next_run`)
+	if _, err := db.Exec(`DELETE FROM job_runs WHERE job_id = ?`, jobID); err != nil {
+		return err
+	}
+
+	cm.mu.Lock()
+	if _, exists := cm.jobs[jobID]; !exists {
+		delete(cm.removed, jobID)
+	}
+	cm.mu.Unlock()
+	return nil
+}
+
+// SaveAllJobsToDB writes jobs changed since the last save to the SQLite DB
+// (upsert semantics) and deletes rows for jobs removed from memory.
+func (cm *CronManager) SaveAllJobsToDB(path string) error {
+	db, err := cm.database(path)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
-	defer stmt.Close()
 
-	cm.mu.RLock()
-	defer cm.mu.RUnlock()
+	// Snapshot pending changes and clear the flags up front so the write runs
+	// without holding the manager lock; they are restored if the write fails.
+	cm.mu.Lock()
+	var saved []*Job
+	var rows [][]any
+	// Soft deletions are written before active jobs for the same reason
+	// removals are in writeJobChanges
+	for _, jobs := range []map[string]*Job{cm.deleted, cm.jobs} {
+		for _, job := range jobs {
+			// Unloadable jobs keep their stored row unless deleted; see
+			// addUnloadableJob
+			if !job.dirty || job.LoadError != "" && job.DeletedAt == nil {
+				continue
+			}
+			saved = append(saved, job)
+			rows = append(rows, jobRowArgs(job))
+			job.dirty = false
+		}
+	}
+	removed := make([]string, 0, len(cm.removed))
+	for id := range cm.removed {
+		removed = append(removed, id)
+	}
+	cm.removed = make(map[string]struct{})
+	cm.mu.Unlock()
+
+	if len(rows) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	if err := writeJobChanges(db, rows, removed); err != nil {
+		cm.mu.Lock()
+		for _, job := range saved {
+			job.dirty = true
+		}
+		for _, id := range removed {
+			_, active := cm.jobs[id]
+			_, deleted := cm.deleted[id]
+			if !active && !deleted {
+				cm.removed[id] = struct{}{}
+			}
+		}
+		cm.mu.Unlock()
+		return err
+	}
+
+	// Fold the WAL back into the main file so backups, which copy only the
+	// main database file, see the latest state.
+	if _, err := db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		slog.Warn("WAL checkpoint failed", "error", err, "path", path)
+	}
+	return nil
+}
+
+// jobColumns is the column order shared by jobRowArgs, the upsert in
+// writeJobChanges and the select in LoadJobsFromDB.
+var jobColumns = []string{
+	"id", "name", "type", "schedule", "schedule_desc", "enabled", "config_json",
+	"last_run", "next_run", "version", "tags_json",
+	"created_by", "created_at", "updated_at",
+	"last_error", "last_error_at", "success_count", "failure_count",
+	"deleted_at", "status", "description", "interval",
+}
+
+// upsertJobSQL builds the insert-or-update statement over jobColumns.
+func upsertJobSQL() string {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(jobColumns)), ",")
+	updates := make([]string, 0, len(jobColumns)-1)
+	for _, col := range jobColumns[1:] {
+		updates = append(updates, col+"=excluded."+col)
+	}
+	return fmt.Sprintf("INSERT INTO jobs(%s) VALUES(%s) ON CONFLICT(id) DO UPDATE SET %s",
+		strings.Join(jobColumns, ","), placeholders, strings.Join(updates, ","))
+}
+
+// jobRowArgs returns the column values for a job in jobColumns order. Must
+// be called with cm.mu held.
+func jobRowArgs(job *Job) []any {
+	cfg, _ := json.Marshal(job.Config)
+	var tagsJSON any
+	if len(job.Tags) > 0 {
+		tags, _ := json.Marshal(job.Tags)
+		tagsJSON = string(tags)
+	}
+	return []any{job.ID, job.Name, string(job.Type), job.Schedule.encode(), job.ScheduleDesc, boolToInt(job.Enabled), string(cfg),
+		unixOrNil(job.LastRun), unixOrNil(job.NextRun), job.Version, tagsJSON,
+		job.CreatedBy, unixOrNil(job.CreatedAt), unixOrNil(job.UpdatedAt),
+		job.LastError, unixOrNil(job.LastErrorAt), job.SuccessCount, job.FailureCount,
+		unixOrNil(job.DeletedAt), string(job.Status), job.Description, job.Interval}
+}
+
+// writeJobChanges upserts rows and deletes removed IDs in a single transaction.
+func writeJobChanges(db *sql.DB, rows [][]any, removed []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
 
-	for _, job := range cm.jobs {
-		cfg, _ := json.Marshal(job.Config)
-		var lastRunUnix, nextRunUnix any
-		if job.LastRun != nil {
-			lastRunUnix = job.LastRun.Unix()
-		} else {
-			lastRunUnix = nil
+	// Removals go first so a new job can take a removed job's name under
+	// the unique name index
+	for _, id := range removed {
+		if _, err := tx.Exec(`DELETE FROM jobs WHERE id = ?`, id); err != nil {
+			tx.Rollback()
+			return err
 		}
-		if job.NextRun != nil {
-			nextRunUnix = job.NextRun.Unix()
-		} else {
-			nextRunUnix = nil
+		if _, err := tx.Exec(`DELETE FROM job_runs WHERE job_id = ?`, id); err != nil {
+			tx.Rollback()
+			return err
 		}
+	}
 
-		if _, err := stmt.Exec(job.ID, job.Name, string(job.Type), job.Schedule, job.ScheduleDesc, boolToInt(job.Enabled), string(cfg), lastRunUnix, nextRunUnix); err != nil {
+	if len(rows) > 0 {
+		stmt, err := tx.Prepare(upsertJobSQL())
+		if err != nil {
 			tx.Rollback()
 			return err
 		}
+		defer stmt.Close()
+
+		for _, args := range rows {
+			if _, err := stmt.Exec(args...); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
 	}
 
 	return tx.Commit()
@@ -105,13 +385,12 @@ func (cm *CronManager) SaveAllJobsToDB(path string) error {
 
 // LoadJobsFromDB reads jobs from sqlite and adds them into the manager (does not start scheduling)
 func (cm *CronManager) LoadJobsFromDB(path string) error {
-	db, err := openDB(path)
+	db, err := cm.database(path)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
-	rows, err := db.Query(`SELECT id,name,type,schedule,schedule_desc,enabled,config_json,last_run,next_run FROM jobs`)
+	rows, err := db.Query(fmt.Sprintf("SELECT %s FROM jobs", strings.Join(jobColumns, ",")))
 	if err != nil {
 		return err
 	}
@@ -121,11 +400,13 @@ func (cm *CronManager) LoadJobsFromDB(path string) error {
 	var loadedCount int
 
 	for rows.Next() {
-		var id, name, typ, schedule, scheduleDesc, configJSON sql.NullString
+		var id, name, typ, schedule, scheduleDesc, configJSON, tagsJSON, createdBy, lastError, status, description, interval sql.NullString
 		var enabled sql.NullInt64
 		var lastRun, nextRun sql.NullInt64
+		var version, createdAt, updatedAt, lastErrorAt sql.NullInt64
+		var successCount, failureCount, deletedAt sql.NullInt64
 
-		if err := rows.Scan(&id, &name, &typ, &schedule, &scheduleDesc, &enabled, &configJSON, &lastRun, &nextRun); err != nil {
+		if err := rows.Scan(&id, &name, &typ, &schedule, &scheduleDesc, &enabled, &configJSON, &lastRun, &nextRun, &version, &tagsJSON, &createdBy, &createdAt, &updatedAt, &lastError, &lastErrorAt, &successCount, &failureCount, &deletedAt, &status, &description, &interval); err != nil {
 			loadErrors = append(loadErrors, fmt.Errorf("failed to scan row: %w", err))
 			continue // Continue loading other rows
 		}
@@ -134,10 +415,22 @@ func (cm *CronManager) LoadJobsFromDB(path string) error {
 			ID:           id.String,
 			Name:         name.String,
 			Type:         JobType(typ.String),
-			Schedule:     schedule.String,
+			Schedule:     decodeScheduleList(schedule.String),
 			ScheduleDesc: scheduleDesc.String,
 			Enabled:      intToBool(int(enabled.Int64)),
 			Config:       map[string]any{},
+			Version:      int(version.Int64),
+			CreatedBy:    createdBy.String,
+			CreatedAt:    timeOrNil(createdAt),
+			UpdatedAt:    timeOrNil(updatedAt),
+			LastError:    lastError.String,
+			LastErrorAt:  timeOrNil(lastErrorAt),
+			SuccessCount: successCount.Int64,
+			FailureCount: failureCount.Int64,
+			DeletedAt:    timeOrNil(deletedAt),
+			Status:       JobStatus(status.String),
+			Description:  description.String,
+			Interval:     interval.String,
 		}
 
 		if configJSON.Valid && configJSON.String != "" {
@@ -147,21 +440,48 @@ func (cm *CronManager) LoadJobsFromDB(path string) error {
 			}
 		}
 
-		if lastRun.Valid {
-			t := time.Unix(lastRun.Int64, 0)
-			j.LastRun = &t
+		if tagsJSON.Valid && tagsJSON.String != "" {
+			var tags []string
+			if err := json.Unmarshal([]byte(tagsJSON.String), &tags); err == nil {
+				j.Tags = tags
+			}
 		}
-		if nextRun.Valid {
-			t := time.Unix(nextRun.Int64, 0)
-			j.NextRun = &t
+
+		j.LastRun = timeOrNil(lastRun)
+		j.NextRun = timeOrNil(nextRun)
+
+		// Soft-deleted jobs stay out of the scheduler until restored
+		if j.DeletedAt != nil {
+			cm.mu.Lock()
+			cm.deleted[j.ID] = j
+			cm.mu.Unlock()
+			continue
+		}
+
+		// Jobs whose type has no executor (e.g. one since removed) are kept,
+		// unscheduled, so they stay visible instead of vanishing
+		if !cm.hasExecutor(j.Type) {
+			cm.addUnloadableJob(j, fmt.Sprintf("unknown job type %q", j.Type))
+			slog.Warn("Loaded job with unknown type as disabled", "job", j.Name, "id", j.ID, "type", j.Type)
+			loadedCount++
+			continue
 		}
 
 		// Add job to manager (this will re-schedule if enabled)
-		// Use AddJob which includes validation and scheduling
-		if err := cm.AddJob(j); err != nil {
-			loadErrors = append(loadErrors, fmt.Errorf("failed to add job %s: %w", j.ID, err))
-			continue // Continue loading other jobs
+		// Use addJob which includes validation and scheduling. Stored jobs
+		// are loaded even if there are more than MaxJobs. Jobs that no
+		// longer pass validation, such as custom commands an operator has
+		// since disallowed, are kept like jobs of unknown types.
+		if err := cm.addJob(j, false, false); err != nil {
+			cm.addUnloadableJob(j, err.Error())
+			slog.Warn("Loaded invalid job as disabled", "job", j.Name, "id", j.ID, "error", err)
+			loadedCount++
+			continue
 		}
+		// Freshly loaded jobs already match their row
+		cm.mu.Lock()
+		j.dirty = false
+		cm.mu.Unlock()
 		loadedCount++
 	}
 
@@ -182,6 +502,52 @@ func (cm *CronManager) LoadJobsFromDB(path string) error {
 	return nil
 }
 
+// hasExecutor reports whether an executor is registered for t
+func (cm *CronManager) hasExecutor(t JobType) bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	_, ok := cm.executors[t]
+	return ok
+}
+
+// addUnloadableJob keeps a stored job in memory as disabled without
+// scheduling it. Syncs skip the job until it is deleted, so its row keeps
+// the stored state, including whether it was enabled, and the job resumes
+// it once the problem is fixed and the jobs are reloaded. Changes such as
+// a rename last only until then.
+func (cm *CronManager) addUnloadableJob(j *Job, reason string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	j.Enabled = false
+	j.NextRun = nil
+	j.CronEntryIDs = nil
+	j.LoadError = reason
+	j.modifiedAt = time.Now()
+	j.Status = jobStatus(j, j.modifiedAt)
+	if j.Version == 0 {
+		j.Version = 1
+	}
+	cm.jobs[j.ID] = j
+	delete(cm.removed, j.ID)
+	cm.revision++
+}
+
+func unixOrNil(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.Unix()
+}
+
+func timeOrNil(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := time.Unix(v.Int64, 0)
+	return &t
+}
+
 func boolToInt(b bool) int {
 	if b {
 		return 1
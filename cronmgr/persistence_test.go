@@ -0,0 +1,274 @@
+package cronmgr
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestManager returns a manager backed by a SQLite file in a temporary
+// directory, allowed to run ls as a custom job
+func newTestManager(t *testing.T, dbPath string) *CronManager {
+	t.Helper()
+	cm := NewCronManager(Config{
+		DBPath:                   dbPath,
+		CustomJobAllowedCommands: []string{"ls"},
+	})
+	t.Cleanup(func() { cm.closeDB() })
+	return cm
+}
+
+// testJob returns a valid enabled custom job whose ID is its name
+func testJob(name string) *Job {
+	return &Job{
+		ID:       name,
+		Name:     name,
+		Type:     CustomJob,
+		Schedule: ScheduleList{"0 0 * * * *"},
+		Enabled:  true,
+		Config:   map[string]any{"command": "ls"},
+	}
+}
+
+func TestRemovedJobStaysGoneAfterReload(t *testing.T) {
+	tests := []struct {
+		name string
+		// syncBeforeRemove saves the job to the database before removing
+		// it, so removing it has a row to delete
+		syncBeforeRemove bool
+	}{
+		{"synced job", true},
+		{"unsynced job", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbPath := filepath.Join(t.TempDir(), "jobs.db")
+			cm := newTestManager(t, dbPath)
+
+			removed, kept := testJob("removed"), testJob("kept")
+			for _, job := range []*Job{removed, kept} {
+				if err := cm.AddJob(job); err != nil {
+					t.Fatalf("AddJob(%s): %v", job.Name, err)
+				}
+			}
+			if tt.syncBeforeRemove {
+				if err := cm.SaveAllJobsToDB(dbPath); err != nil {
+					t.Fatalf("SaveAllJobsToDB: %v", err)
+				}
+			}
+			if err := cm.RemoveJob(removed.ID); err != nil {
+				t.Fatalf("RemoveJob: %v", err)
+			}
+			if err := cm.SaveAllJobsToDB(dbPath); err != nil {
+				t.Fatalf("SaveAllJobsToDB: %v", err)
+			}
+			cm.closeDB()
+
+			reloaded := newTestManager(t, dbPath)
+			if err := reloaded.LoadJobsFromDB(dbPath); err != nil {
+				t.Fatalf("LoadJobsFromDB: %v", err)
+			}
+			if _, err := reloaded.GetJob(removed.ID); !errors.Is(err, ErrJobNotFound) {
+				t.Errorf("GetJob(removed) error = %v, want %v", err, ErrJobNotFound)
+			}
+			if _, err := reloaded.GetJob(kept.ID); err != nil {
+				t.Errorf("GetJob(kept): %v", err)
+			}
+		})
+	}
+}
+
+func TestRemovedJobRowIsDeletedBeforeSync(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "jobs.db")
+	cm := newTestManager(t, dbPath)
+
+	job := testJob("removed")
+	if err := cm.AddJob(job); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	if err := cm.SaveAllJobsToDB(dbPath); err != nil {
+		t.Fatalf("SaveAllJobsToDB: %v", err)
+	}
+	// No sync follows the removal, as when the process exits right after it
+	if err := cm.RemoveJob(job.ID); err != nil {
+		t.Fatalf("RemoveJob: %v", err)
+	}
+	cm.closeDB()
+
+	reloaded := newTestManager(t, dbPath)
+	if err := reloaded.LoadJobsFromDB(dbPath); err != nil {
+		t.Fatalf("LoadJobsFromDB: %v", err)
+	}
+	if _, err := reloaded.GetJob(job.ID); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("GetJob error = %v, want %v", err, ErrJobNotFound)
+	}
+}
+
+// legacyExecutor stands in for an executor registered by an embedder
+type legacyExecutor struct{}
+
+func (legacyExecutor) Execute(map[string]any) error  { return nil }
+func (legacyExecutor) Validate(map[string]any) error { return nil }
+
+func TestUnknownJobTypeKeepsStoredRow(t *testing.T) {
+	const legacy JobType = "legacy"
+	dbPath := filepath.Join(t.TempDir(), "jobs.db")
+
+	cm := newTestManager(t, dbPath)
+	if err := cm.RegisterExecutor(legacy, legacyExecutor{}); err != nil {
+		t.Fatalf("RegisterExecutor: %v", err)
+	}
+	job := &Job{ID: "old", Name: "old", Type: legacy, Schedule: ScheduleList{"0 0 * * * *"}, Enabled: true, Config: map[string]any{}}
+	if err := cm.AddJob(job); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	if err := cm.SaveAllJobsToDB(dbPath); err != nil {
+		t.Fatalf("SaveAllJobsToDB: %v", err)
+	}
+	cm.closeDB()
+
+	// Without the executor the job loads disabled, with the reason
+	unloaded := newTestManager(t, dbPath)
+	if err := unloaded.LoadJobsFromDB(dbPath); err != nil {
+		t.Fatalf("LoadJobsFromDB: %v", err)
+	}
+	got, err := unloaded.GetJob("old")
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if got.Enabled || got.LoadError == "" || got.Status != StatusError {
+		t.Errorf("unloadable job has enabled %t, load error %q, status %q", got.Enabled, got.LoadError, got.Status)
+	}
+	if _, err := unloaded.ToggleJob("old"); !errors.Is(err, ErrUnknownJobType) {
+		t.Errorf("ToggleJob error = %v, want %v", err, ErrUnknownJobType)
+	}
+	// A change while unloadable must not overwrite the stored row
+	if _, err := unloaded.RenameJob("old", "renamed", 0); err != nil {
+		t.Fatalf("RenameJob: %v", err)
+	}
+	if err := unloaded.SaveAllJobsToDB(dbPath); err != nil {
+		t.Fatalf("SaveAllJobsToDB: %v", err)
+	}
+	unloaded.closeDB()
+
+	// Once the executor is back the job resumes its stored state
+	restored := newTestManager(t, dbPath)
+	if err := restored.RegisterExecutor(legacy, legacyExecutor{}); err != nil {
+		t.Fatalf("RegisterExecutor: %v", err)
+	}
+	if err := restored.LoadJobsFromDB(dbPath); err != nil {
+		t.Fatalf("LoadJobsFromDB: %v", err)
+	}
+	got, err = restored.GetJob("old")
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if !got.Enabled || got.LoadError != "" || got.Name != "old" {
+		t.Errorf("reloaded job has enabled %t, load error %q, name %q; want the stored state", got.Enabled, got.LoadError, got.Name)
+	}
+}
+
+func TestRenameKeepsLastRun(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "jobs.db")
+	cm := newTestManager(t, dbPath)
+
+	job := testJob("job")
+	if err := cm.AddJob(job); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	lastRun := time.Now().Add(-time.Hour).Truncate(time.Second)
+	cm.mu.Lock()
+	job.LastRun = &lastRun
+	job.SuccessCount = 3
+	cm.mu.Unlock()
+
+	renamed, err := cm.RenameJob("job", "renamed", 0)
+	if err != nil {
+		t.Fatalf("RenameJob: %v", err)
+	}
+	if renamed.LastRun == nil || !renamed.LastRun.Equal(lastRun) || renamed.SuccessCount != 3 {
+		t.Errorf("RenameJob returned last run %v, success count %d; want %v, 3", renamed.LastRun, renamed.SuccessCount, lastRun)
+	}
+	// The result is a copy, so changing it leaves the manager's job alone
+	renamed.Name = "changed"
+	if got, _ := cm.GetJob("job"); got.Name != "renamed" {
+		t.Errorf("job name = %q after changing RenameJob's result, want %q", got.Name, "renamed")
+	}
+	cm.closeDB()
+
+	reloaded := newTestManager(t, dbPath)
+	if err := reloaded.LoadJobsFromDB(dbPath); err != nil {
+		t.Fatalf("LoadJobsFromDB: %v", err)
+	}
+	got, err := reloaded.GetJob("job")
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if got.Name != "renamed" || got.LastRun == nil || !got.LastRun.Equal(lastRun) || got.SuccessCount != 3 {
+		t.Errorf("reloaded job has name %q, last run %v, success count %d; want %q, %v, 3", got.Name, got.LastRun, got.SuccessCount, "renamed", lastRun)
+	}
+}
+
+func TestDisallowedCustomJobStaysVisible(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "jobs.db")
+	cm := newTestManager(t, dbPath)
+	if err := cm.AddJob(testJob("job")); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	if err := cm.SaveAllJobsToDB(dbPath); err != nil {
+		t.Fatalf("SaveAllJobsToDB: %v", err)
+	}
+	cm.closeDB()
+
+	// As after an upgrade to a version that allows no commands by default
+	denied := NewCronManager(Config{DBPath: dbPath})
+	t.Cleanup(func() { denied.closeDB() })
+	if err := denied.LoadJobsFromDB(dbPath); err != nil {
+		t.Fatalf("LoadJobsFromDB: %v", err)
+	}
+	got, err := denied.GetJob("job")
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if got.Enabled || got.Status != StatusError || !strings.Contains(got.LoadError, "no commands are allowed") {
+		t.Errorf("job has enabled %t, status %q, load error %q", got.Enabled, got.Status, got.LoadError)
+	}
+	if _, err := denied.ToggleJob("job"); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("ToggleJob error = %v, want %v", err, ErrInvalidConfig)
+	}
+	if err := denied.SaveAllJobsToDB(dbPath); err != nil {
+		t.Fatalf("SaveAllJobsToDB: %v", err)
+	}
+	denied.closeDB()
+
+	// Allowing the command again brings the job back as it was stored
+	allowed := newTestManager(t, dbPath)
+	if err := allowed.LoadJobsFromDB(dbPath); err != nil {
+		t.Fatalf("LoadJobsFromDB: %v", err)
+	}
+	got, err = allowed.GetJob("job")
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if !got.Enabled || got.LoadError != "" {
+		t.Errorf("reloaded job has enabled %t, load error %q", got.Enabled, got.LoadError)
+	}
+}
+
+func TestGetJobReturnsCopy(t *testing.T) {
+	cm := newTestManager(t, filepath.Join(t.TempDir(), "jobs.db"))
+	if err := cm.AddJob(testJob("job")); err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	got, err := cm.GetJob("job")
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	got.Name = "changed"
+	cm.GetAllJobs()[0].Name = "changed"
+	if got, _ := cm.GetJob("job"); got.Name != "job" {
+		t.Errorf("job name = %q after changing a returned job, want %q", got.Name, "job")
+	}
+}
@@ -0,0 +1,59 @@
+package cronmgr
+
+import "strings"
+
+// SchedulePreset is a named shorthand accepted in a job's schedule field
+type SchedulePreset struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Label      string `json:"label"`
+}
+
+// schedulePresets are expanded to their cron expression by AddJob. They are
+// written with a seconds field; presetExpression drops it for schedules
+// without one.
+var schedulePresets = []SchedulePreset{
+	{Name: "every-minute", Expression: "0 * * * * *", Label: "Every minute"},
+	{Name: "every-5-minutes", Expression: "0 */5 * * * *", Label: "Every 5 minutes"},
+	{Name: "every-15-minutes", Expression: "0 */15 * * * *", Label: "Every 15 minutes"},
+	{Name: "hourly", Expression: "0 0 * * * *", Label: "Every hour"},
+	{Name: "daily-midnight", Expression: "0 0 0 * * *", Label: "Every day at midnight"},
+	{Name: "daily-9am", Expression: "0 0 9 * * *", Label: "Every day at 9:00 AM"},
+	{Name: "weekdays-midnight", Expression: "0 0 0 * * 1-5", Label: "Weekdays at midnight"},
+	{Name: "weekdays-9am", Expression: "0 0 9 * * 1-5", Label: "Weekdays at 9:00 AM"},
+	{Name: "weekly", Expression: "0 0 0 * * 0", Label: "Every Sunday at midnight"},
+	{Name: "monthly", Expression: "0 0 0 1 * *", Label: "First day of every month at midnight"},
+}
+
+// SchedulePresets returns the named schedule presets, with six-field
+// expressions if seconds is set and five-field ones otherwise
+func SchedulePresets(seconds bool) []SchedulePreset {
+	presets := make([]SchedulePreset, len(schedulePresets))
+	for i, p := range schedulePresets {
+		p.Expression = presetExpression(p, seconds)
+		presets[i] = p
+	}
+	return presets
+}
+
+// presetExpression returns a preset's expression with or without its
+// seconds field
+func presetExpression(p SchedulePreset, seconds bool) string {
+	if seconds {
+		return p.Expression
+	}
+	_, expr, _ := strings.Cut(p.Expression, " ")
+	return expr
+}
+
+// expandSchedulePreset returns the cron expression for a preset name, or the
+// schedule unchanged if it isn't one.
+func expandSchedulePreset(schedule string, seconds bool) string {
+	name := strings.ToLower(strings.TrimSpace(schedule))
+	for _, p := range schedulePresets {
+		if p.Name == name {
+			return presetExpression(p, seconds)
+		}
+	}
+	return schedule
+}
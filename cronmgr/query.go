@@ -0,0 +1,397 @@
+package cronmgr
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// defaultQueryTimeout bounds a query job when its config sets no timeout
+const defaultQueryTimeout = 30 * time.Second
+
+// QueryJobExecutor runs a SQL statement against a configured database and
+// optionally fails the run when the result exceeds a threshold.
+//
+// Config fields:
+//   - dsn: data source name (required)
+//   - query: SQL to run (required)
+//   - driver: database/sql driver name, default "sqlite3"
+//   - threshold: if set, the run fails when the first column of the first
+//     row is greater than this number
+//   - timeout: Go duration bounding the query, default 30s
+//
+// Jobs may only use the drivers and DSNs the operator allowed, and never the
+// files in ProtectedPaths. SQLite databases are opened read-only and
+// statements that would write, attach or vacuum are refused. Columns and
+// JSON keys that look like secrets are masked in the output.
+type QueryJobExecutor struct {
+	// AllowedDSNs lists the DSNs jobs may use, compared with the dsn as
+	// written in the job, secret references included
+	AllowedDSNs []string
+	// AllowedDrivers lists the drivers jobs may use; empty allows only
+	// sqlite3
+	AllowedDrivers []string
+	// ProtectedPaths are SQLite files jobs may never open, such as the
+	// jobs database
+	ProtectedPaths []string
+}
+
+func (q *QueryJobExecutor) Execute(config map[string]any) error {
+	_, err := q.ExecuteWithOutput(context.Background(), config)
+	return err
+}
+
+func (q *QueryJobExecutor) ExecuteWithOutput(ctx context.Context, config map[string]any) (string, error) {
+	driver := queryDriver(config)
+	dsn, _ := config["dsn"].(string)
+	query, _ := config["query"].(string)
+	storedDSN, _ := loggableConfig(ctx, config)["dsn"].(string)
+	if err := q.checkAllowed(driver, storedDSN); err != nil {
+		return "", err
+	}
+	if driver == "sqlite3" {
+		if err := q.checkProtected(dsn); err != nil {
+			return "", err
+		}
+		ro, err := readOnlySQLiteDSN(dsn)
+		if err != nil {
+			return "", err
+		}
+		dsn = ro
+	}
+
+	timeout := defaultQueryTimeout
+	if v, ok := config["timeout"].(string); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid timeout: %w", err)
+		}
+		timeout = d
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// The DSN may carry credentials, so only the driver is logged
+	RunLogger(ctx).Info("Running query", "driver", driver)
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return "", fmt.Errorf("open database: %w", err)
+	}
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return "", fmt.Errorf("open database: %w", err)
+	}
+	defer conn.Close()
+	if driver == "sqlite3" {
+		if err := conn.Raw(authorizeReadOnly); err != nil {
+			return "", err
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return "", fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", fmt.Errorf("columns: %w", err)
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", fmt.Errorf("query: %w", err)
+		}
+		return "(no rows)", nil
+	}
+
+	values := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return "", fmt.Errorf("scan: %w", err)
+	}
+	output := formatRow(cols, values)
+
+	threshold, hasThreshold, err := queryThreshold(config)
+	if err != nil {
+		return output, err
+	}
+	if hasThreshold && len(values) > 0 {
+		v, err := toFloat(values[0])
+		if err != nil {
+			return output, fmt.Errorf("result %q is not numeric: %w", cols[0], err)
+		}
+		if v > threshold {
+			return output, fmt.Errorf("result %v exceeds threshold %v", v, threshold)
+		}
+	}
+	return output, nil
+}
+
+// DryRun reports the query without connecting, since opening some databases
+// (such as a missing SQLite file) creates them
+func (q *QueryJobExecutor) DryRun(ctx context.Context, config map[string]any) (string, error) {
+	query, _ := config["query"].(string)
+	dsn, _ := config["dsn"].(string)
+	if err := q.checkAllowed(queryDriver(config), dsn); err != nil {
+		return "", err
+	}
+	out := fmt.Sprintf("Would run on %s database:\n%s", queryDriver(config), strings.TrimSpace(query))
+	threshold, hasThreshold, err := queryThreshold(config)
+	if err != nil {
+		return out, err
+	}
+	if hasThreshold {
+		out += fmt.Sprintf("\nfailing if the result exceeds %v", threshold)
+	}
+	return out, nil
+}
+
+func (q *QueryJobExecutor) ConfigSchema() []ConfigField {
+	return []ConfigField{
+		{Name: "dsn", Type: FieldString, Required: true, Sensitive: true, Description: "Data source name"},
+		{Name: "query", Type: FieldText, Required: true, Description: "SQL to run"},
+		{Name: "driver", Type: FieldString, Description: "database/sql driver name (default sqlite3)"},
+		{Name: "threshold", Type: FieldNumber, Description: "Fail when the first value of the result exceeds this"},
+		{Name: "timeout", Type: FieldDuration, Description: "Query timeout (default 30s)"},
+	}
+}
+
+func (q *QueryJobExecutor) Validate(config map[string]any) error {
+	if v, ok := config["dsn"].(string); !ok || v == "" {
+		return fmt.Errorf("'dsn' field is required")
+	}
+	if v, ok := config["query"].(string); !ok || strings.TrimSpace(v) == "" {
+		return fmt.Errorf("'query' field is required")
+	}
+	driver := queryDriver(config)
+	if !slices.Contains(sql.Drivers(), driver) {
+		return fmt.Errorf("unknown database driver: %s", driver)
+	}
+	dsn := config["dsn"].(string)
+	if err := q.checkAllowed(driver, dsn); err != nil {
+		return err
+	}
+	if driver == "sqlite3" {
+		if err := q.checkProtected(dsn); err != nil {
+			return err
+		}
+	}
+	if _, _, err := queryThreshold(config); err != nil {
+		return err
+	}
+	if raw, ok := config["timeout"]; ok {
+		v, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("'timeout' must be a duration string")
+		}
+		if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+			return fmt.Errorf("'timeout' must be a positive duration")
+		}
+	}
+	return nil
+}
+
+// checkAllowed rejects a driver or DSN the operator hasn't allowed. The DSN
+// isn't echoed, since it may carry credentials.
+func (q *QueryJobExecutor) checkAllowed(driver, dsn string) error {
+	drivers := q.AllowedDrivers
+	if len(drivers) == 0 {
+		drivers = []string{"sqlite3"}
+	}
+	if !slices.Contains(drivers, driver) {
+		return fmt.Errorf("database driver %s is not allowed", driver)
+	}
+	if !slices.Contains(q.AllowedDSNs, dsn) {
+		return fmt.Errorf("'dsn' is not one of the allowed data sources")
+	}
+	return nil
+}
+
+// checkProtected rejects a SQLite DSN naming one of ProtectedPaths
+func (q *QueryJobExecutor) checkProtected(dsn string) error {
+	path, err := sqlitePath(dsn)
+	if err != nil {
+		return err
+	}
+	for _, protected := range q.ProtectedPaths {
+		if sameFile(path, protected) {
+			return fmt.Errorf("'dsn' must not refer to the jobs database")
+		}
+	}
+	return nil
+}
+
+// sqlitePath returns the file a SQLite DSN opens, either a plain path or a
+// "file:" URI, without its query parameters
+func sqlitePath(dsn string) (string, error) {
+	path, _, _ := strings.Cut(dsn, "?")
+	rest, isURI := strings.CutPrefix(path, "file:")
+	if !isURI {
+		return path, nil
+	}
+	// file://host/path names a local file only for an empty or localhost host
+	if after, ok := strings.CutPrefix(rest, "//"); ok {
+		host, p, _ := strings.Cut(after, "/")
+		if host != "" && host != "localhost" {
+			return "", fmt.Errorf("'dsn' names a remote host")
+		}
+		rest = "/" + p
+	}
+	return url.PathUnescape(rest)
+}
+
+// sameFile reports whether two paths name the same file, following links
+// when both exist
+func sameFile(a, b string) bool {
+	ai, aErr := os.Stat(a)
+	bi, bErr := os.Stat(b)
+	if aErr == nil && bErr == nil {
+		return os.SameFile(ai, bi)
+	}
+	absA, aErr := filepath.Abs(a)
+	absB, bErr := filepath.Abs(b)
+	return aErr == nil && bErr == nil && absA == absB
+}
+
+// readOnlySQLiteDSN turns a SQLite DSN into a "file:" URI opened with
+// mode=ro, overriding any mode it set
+func readOnlySQLiteDSN(dsn string) (string, error) {
+	path, rawQuery, _ := strings.Cut(dsn, "?")
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("invalid 'dsn' parameters: %w", err)
+	}
+	params.Set("mode", "ro")
+	if !strings.HasPrefix(path, "file:") {
+		path = "file:" + path
+	}
+	return path + "?" + params.Encode(), nil
+}
+
+// sqliteRecursive is SQLITE_RECURSIVE, which the driver doesn't export
+const sqliteRecursive = 33
+
+// authorizeReadOnly makes a SQLite connection refuse everything but reads.
+// mode=ro alone still lets ATTACH create files and VACUUM INTO write one.
+func authorizeReadOnly(driverConn any) error {
+	conn, ok := driverConn.(*sqlite3.SQLiteConn)
+	if !ok {
+		return errors.New("unexpected SQLite connection type")
+	}
+	conn.RegisterAuthorizer(func(op int, _, _, _ string) int {
+		switch op {
+		case sqlite3.SQLITE_SELECT, sqlite3.SQLITE_READ, sqlite3.SQLITE_FUNCTION, sqliteRecursive:
+			return sqlite3.SQLITE_OK
+		}
+		return sqlite3.SQLITE_DENY
+	})
+	return nil
+}
+
+func queryDriver(config map[string]any) string {
+	if v, ok := config["driver"].(string); ok && v != "" {
+		return v
+	}
+	return "sqlite3"
+}
+
+func queryThreshold(config map[string]any) (float64, bool, error) {
+	raw, ok := config["threshold"]
+	if !ok || raw == nil {
+		return 0, false, nil
+	}
+	v, err := toFloat(raw)
+	if err != nil {
+		return 0, false, fmt.Errorf("'threshold' must be a number")
+	}
+	return v, true, nil
+}
+
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case []byte:
+		return strconv.ParseFloat(string(n), 64)
+	case string:
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// formatRow renders a result row as "col=value" pairs. Values of columns
+// named like secrets are masked, as are secret keys of JSON objects.
+func formatRow(cols []string, values []any) string {
+	parts := make([]string, len(cols))
+	for i, col := range cols {
+		v := values[i]
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		if s, ok := v.(string); ok {
+			v = redactJSONText(s)
+		}
+		if sensitiveKeyPattern.MatchString(col) && v != nil {
+			v = redactedValue
+		}
+		parts[i] = fmt.Sprintf("%s=%v", col, v)
+	}
+	return strings.Join(parts, " ")
+}
+
+// redactJSONText masks the values of secret keys, and of every key nested
+// under one, in s if it is a JSON object. Anything else is returned as is.
+func redactJSONText(s string) string {
+	var obj map[string]any
+	if !strings.HasPrefix(strings.TrimSpace(s), "{") || json.Unmarshal([]byte(s), &obj) != nil {
+		return s
+	}
+	b, err := json.Marshal(redactJSONValue(obj, false))
+	if err != nil {
+		return redactedValue
+	}
+	return string(b)
+}
+
+func redactJSONValue(v any, sensitive bool) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, nv := range t {
+			t[k] = redactJSONValue(nv, sensitive || sensitiveKeyPattern.MatchString(k))
+		}
+		return t
+	case []any:
+		for i, nv := range t {
+			t[i] = redactJSONValue(nv, sensitive)
+		}
+		return t
+	}
+	if sensitive {
+		return redactedValue
+	}
+	return v
+}
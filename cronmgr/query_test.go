@@ -0,0 +1,104 @@
+package cronmgr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQueryJobsDisabledByDefault(t *testing.T) {
+	cm := newTestManager(t, filepath.Join(t.TempDir(), "jobs.db"))
+	job := &Job{ID: "q", Name: "q", Type: QueryJob, Schedule: ScheduleList{"0 0 * * * *"}, Config: map[string]any{"dsn": "reports.db", "query": "SELECT 1"}}
+	if err := cm.AddJob(job); !errors.Is(err, ErrUnknownJobType) {
+		t.Errorf("AddJob error = %v, want %v", err, ErrUnknownJobType)
+	}
+}
+
+func TestQueryJobValidateDSN(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "jobs.db")
+	q := &QueryJobExecutor{
+		AllowedDSNs:    []string{"reports.db", dbPath, "file:" + dbPath + "?cache=shared", "file://localhost" + dbPath},
+		ProtectedPaths: []string{dbPath},
+	}
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr string
+	}{
+		{name: "allowed", config: map[string]any{"dsn": "reports.db", "query": "SELECT 1"}},
+		{name: "not allowed", config: map[string]any{"dsn": "other.db", "query": "SELECT 1"}, wantErr: "allowed data sources"},
+		{name: "driver not allowed", config: map[string]any{"dsn": "reports.db", "query": "SELECT 1", "driver": "sqlite3_extended"}, wantErr: "driver"},
+		{name: "jobs database", config: map[string]any{"dsn": dbPath, "query": "SELECT 1"}, wantErr: "jobs database"},
+		{name: "jobs database as URI", config: map[string]any{"dsn": "file:" + dbPath + "?cache=shared", "query": "SELECT 1"}, wantErr: "jobs database"},
+		{name: "jobs database on localhost", config: map[string]any{"dsn": "file://localhost" + dbPath, "query": "SELECT 1"}, wantErr: "jobs database"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := q.Validate(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueryJobIsReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "reports.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE users(name TEXT, password TEXT, settings TEXT);
+		INSERT INTO users VALUES('ann', 'hunter2', '{"theme":"dark","api_key":"k1","smtp":{"secret":"s1"}}')`); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	db.Close()
+
+	q := &QueryJobExecutor{AllowedDSNs: []string{dbPath}}
+	run := func(query string) (string, error) {
+		return q.ExecuteWithOutput(context.Background(), map[string]any{"dsn": dbPath, "query": query})
+	}
+
+	out, err := run("SELECT name, password, settings FROM users")
+	if err != nil {
+		t.Fatalf("SELECT: %v", err)
+	}
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "k1") || strings.Contains(out, "s1") || !strings.Contains(out, "name=ann") || !strings.Contains(out, `"theme":"dark"`) {
+		t.Errorf("output %q should show the name and theme but mask the secrets", out)
+	}
+
+	attached := filepath.Join(dir, "attached.db")
+	vacuumed := filepath.Join(dir, "copy.db")
+	for _, query := range []string{
+		"INSERT INTO users VALUES('eve', 'x', '{}')",
+		"ATTACH '" + attached + "' AS a",
+		"SELECT 1; ATTACH '" + attached + "' AS a",
+		"VACUUM INTO '" + vacuumed + "'",
+		"PRAGMA query_only=0",
+		"CREATE TEMP TABLE t(x)",
+	} {
+		if _, err := run(query); err == nil {
+			t.Errorf("%s succeeded, want it refused", query)
+		}
+	}
+	for _, path := range []string{attached, vacuumed} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was created", path)
+		}
+	}
+	if out, err := run("SELECT count(*) FROM users"); err != nil || out != "count(*)=1" {
+		t.Errorf("count after refused writes = %q, %v; want 1 row", out, err)
+	}
+}
@@ -0,0 +1,123 @@
+package cronmgr
+
+import (
+	"crypto/subtle"
+	"maps"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// redactedValue replaces sensitive config values in API responses
+const redactedValue = "***"
+
+// sensitiveKeyPattern matches config keys treated as secrets for every job
+// type, in addition to the fields an executor's schema marks Sensitive
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|credential)`)
+
+// sensitiveKeysLocked returns the config keys of jobType whose values are
+// secret. Must be called with cm.mu held.
+func (cm *CronManager) sensitiveKeysLocked(jobType JobType) map[string]bool {
+	keys := make(map[string]bool)
+	for _, f := range commonConfigFields {
+		if f.Sensitive {
+			keys[f.Name] = true
+		}
+	}
+	if s, ok := cm.executors[jobType].(SchemaExecutor); ok {
+		for _, f := range s.ConfigSchema() {
+			if f.Sensitive {
+				keys[f.Name] = true
+			}
+		}
+	}
+	return keys
+}
+
+// isSensitive reports whether key holds a secret for a job type with the
+// given sensitive keys
+func isSensitive(key string, keys map[string]bool) bool {
+	return keys[key] || sensitiveKeyPattern.MatchString(key)
+}
+
+// redactedConfig returns a copy of a job's config with secret values
+// replaced by redactedValue. Objects such as env keep their keys but have
+// every value masked.
+func (cm *CronManager) redactedConfig(job *Job) map[string]any {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if job.Config == nil {
+		return nil
+	}
+	keys := cm.sensitiveKeysLocked(job.Type)
+	config := maps.Clone(job.Config)
+	for k, v := range config {
+		if nested, ok := v.(map[string]any); ok {
+			masked := make(map[string]any, len(nested))
+			for nk, nv := range nested {
+				if isSensitive(k, keys) || isSensitive(nk, keys) {
+					nv = redactedValue
+				}
+				masked[nk] = nv
+			}
+			config[k] = masked
+			continue
+		}
+		if isSensitive(k, keys) && v != "" {
+			config[k] = redactedValue
+		}
+	}
+	return config
+}
+
+// keepRedactedSecrets puts back existing secret values wherever an update
+// echoes redactedValue, so a client can save a job it read without
+// clobbering its secrets.
+func (cm *CronManager) keepRedactedSecrets(jobID string, jobType JobType, config map[string]any) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	existing, ok := cm.jobs[jobID]
+	if !ok {
+		return
+	}
+	keys := cm.sensitiveKeysLocked(jobType)
+	for k, v := range config {
+		if v == redactedValue && isSensitive(k, keys) {
+			if old, ok := existing.Config[k]; ok {
+				config[k] = old
+			}
+			continue
+		}
+		nested, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		oldNested, _ := existing.Config[k].(map[string]any)
+		for nk, nv := range nested {
+			if nv != redactedValue || !(isSensitive(k, keys) || isSensitive(nk, keys)) {
+				continue
+			}
+			if old, ok := oldNested[nk]; ok {
+				nested[nk] = old
+			}
+		}
+	}
+}
+
+// revealAuthorized reports whether r carries the configured reveal token as
+// a bearer token. Revealing is disabled when no token is configured.
+func (cm *CronManager) revealAuthorized(r *http.Request) bool {
+	return bearerAuthorized(r, cm.config.RevealToken)
+}
+
+// bearerAuthorized reports whether r carries want as a bearer token. An
+// empty want never matches.
+func bearerAuthorized(r *http.Request, want string) bool {
+	if want == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+}
@@ -0,0 +1,98 @@
+package cronmgr
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"tapasrm.dev/cron-ui/storage"
+)
+
+// RunLocker lets instances that share a backend agree on which of them
+// executes each scheduled run, so a clustered deployment runs a job once
+// per firing rather than once per instance.
+type RunLocker interface {
+	// Claim reports whether this instance won the run of jobID identified
+	// by key. Only the first claim of a key succeeds.
+	Claim(ctx context.Context, jobID, key string) (bool, error)
+}
+
+// DefaultRunClaimRetention is how long StorageRunLocker keeps claim markers
+const DefaultRunClaimRetention = 24 * time.Hour
+
+// runClaimTimeout bounds each attempt to claim a run
+const runClaimTimeout = 10 * time.Second
+
+// StorageRunLocker claims runs by creating a marker file per job firing,
+// <job id>/<scheduled time>, in a store every instance shares. Creating a
+// file is atomic, so exactly one instance's claim succeeds. Markers older
+// than Retention are deleted as the job's later runs are claimed.
+type StorageRunLocker struct {
+	Store storage.Storage
+	// Owner is written into each marker to show which instance claimed it
+	Owner string
+	// Retention defaults to DefaultRunClaimRetention
+	Retention time.Duration
+}
+
+func (l StorageRunLocker) Claim(ctx context.Context, jobID, key string) (bool, error) {
+	dir, err := storage.SanitizeFileName(jobID)
+	if err != nil {
+		return false, err
+	}
+	_, err = l.Store.CreateFile(ctx, dir+"/"+key, strings.NewReader(l.Owner))
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	l.prune(ctx, dir+"/")
+	return true, nil
+}
+
+// prune deletes the markers under prefix older than the retention window.
+// Failures are only logged; a later claim tries again.
+func (l StorageRunLocker) prune(ctx context.Context, prefix string) {
+	retention := l.Retention
+	if retention <= 0 {
+		retention = DefaultRunClaimRetention
+	}
+	files, err := l.Store.ListFiles(ctx, prefix)
+	if err != nil {
+		slog.Warn("Failed to list run claims", "prefix", prefix, "error", err)
+		return
+	}
+	cutoff := time.Now().Add(-retention)
+	for _, f := range files {
+		if f.LastModified == nil || !f.LastModified.Before(cutoff) {
+			continue
+		}
+		if err := l.Store.DeleteFile(ctx, f.Name); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			slog.Warn("Failed to delete run claim", "name", f.Name, "error", err)
+		}
+	}
+}
+
+// claimRun reports whether this instance should execute the run of jobID
+// scheduled at scheduled. Without a RunLocker every instance runs its own
+// schedule. If the claim can't be made the run is skipped rather than risk
+// running it twice.
+func (cm *CronManager) claimRun(jobName, jobID string, scheduled time.Time) bool {
+	if cm.config.RunLocker == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(cm.runCtx, runClaimTimeout)
+	defer cancel()
+	won, err := cm.config.RunLocker.Claim(ctx, jobID, scheduled.UTC().Format("20060102T150405Z"))
+	if err != nil {
+		slog.Error("Skipping job run, could not claim it", "job", jobName, "id", jobID, "scheduled", scheduled, "error", err)
+		return false
+	}
+	if !won {
+		slog.Info("Skipping job run claimed by another instance", "job", jobName, "id", jobID, "scheduled", scheduled)
+	}
+	return won
+}
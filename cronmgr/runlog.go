@@ -0,0 +1,320 @@
+package cronmgr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"tapasrm.dev/cron-ui/apierror"
+)
+
+// ErrRunNotFound is returned when a job has no run with the requested ID
+var ErrRunNotFound = errors.New("run not found")
+
+// maxRunLog caps how much log text is kept per run; later lines are dropped
+const maxRunLog = 256 * 1024
+
+// runLogTruncated is appended once a run's log reaches maxRunLog
+const runLogTruncated = "[log truncated]\n"
+
+// runLog collects one run's log as it executes: the manager's log records
+// for the run, in slog's text format, followed by executor output as it is
+// written. Followers wait on changed, which is closed and replaced whenever
+// the log grows or the run ends.
+type runLog struct {
+	mu        sync.Mutex
+	buf       []byte
+	truncated bool
+	streamed  bool // the executor wrote output through output()
+	done      bool
+	changed   chan struct{}
+}
+
+func newRunLog() *runLog {
+	return &runLog{changed: make(chan struct{})}
+}
+
+func (l *runLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.appendLocked(p)
+	return len(p), nil
+}
+
+func (l *runLog) appendLocked(p []byte) {
+	if l.truncated || len(p) == 0 {
+		return
+	}
+	if room := maxRunLog - len(l.buf); len(p) > room {
+		l.buf = append(l.buf, p[:room]...)
+		l.buf = append(l.buf, "\n"+runLogTruncated...)
+		l.truncated = true
+	} else {
+		l.buf = append(l.buf, p...)
+	}
+	l.notifyLocked()
+}
+
+func (l *runLog) notifyLocked() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// output returns the writer executors stream their output to
+func (l *runLog) output() io.Writer {
+	return runOutputWriter{l}
+}
+
+// addOutput appends output an executor returned, unless it already
+// streamed it
+func (l *runLog) addOutput(output string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.streamed {
+		return
+	}
+	l.appendLocked([]byte(output))
+}
+
+// finish marks the run as ended, waking any followers
+func (l *runLog) finish() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.done = true
+	l.notifyLocked()
+}
+
+// since returns the log from offset on, the offset to read from next,
+// whether the run has ended, and a channel closed when that may change.
+func (l *runLog) since(offset int) ([]byte, int, bool, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf[offset:len(l.buf):len(l.buf)], len(l.buf), l.done, l.changed
+}
+
+func (l *runLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return string(l.buf)
+}
+
+// runOutputWriter records executor output in a run log, noting that the
+// executor streamed it so it isn't added again when the run returns
+type runOutputWriter struct {
+	l *runLog
+}
+
+func (w runOutputWriter) Write(p []byte) (int, error) {
+	w.l.mu.Lock()
+	defer w.l.mu.Unlock()
+	w.l.streamed = true
+	w.l.appendLocked(p)
+	return len(p), nil
+}
+
+// teeHandler passes each record to both handlers, so a run's records reach
+// the process log as well as the run log
+type teeHandler struct {
+	a, b slog.Handler
+}
+
+func (h teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.a.Enabled(ctx, level) || h.b.Enabled(ctx, level)
+}
+
+func (h teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errA, errB error
+	if h.a.Enabled(ctx, r.Level) {
+		errA = h.a.Handle(ctx, r.Clone())
+	}
+	if h.b.Enabled(ctx, r.Level) {
+		errB = h.b.Handle(ctx, r)
+	}
+	return errors.Join(errA, errB)
+}
+
+func (h teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return teeHandler{h.a.WithAttrs(attrs), h.b.WithAttrs(attrs)}
+}
+
+func (h teeHandler) WithGroup(name string) slog.Handler {
+	return teeHandler{h.a.WithGroup(name), h.b.WithGroup(name)}
+}
+
+// runLogKey is the context key carrying the current run's log
+type runLogKey struct{}
+
+// withRunLog returns a context carrying l for executors to log to
+func withRunLog(ctx context.Context, l *runLog) context.Context {
+	return context.WithValue(ctx, runLogKey{}, l)
+}
+
+// RunLogger returns a logger for an executor to use during a run, whose
+// records are kept in the run's log as well as written to the process log.
+// Outside a run it is slog.Default().
+func RunLogger(ctx context.Context) *slog.Logger {
+	l, ok := ctx.Value(runLogKey{}).(*runLog)
+	if !ok {
+		return slog.Default()
+	}
+	return slog.New(teeHandler{slog.Default().Handler(), slog.NewTextHandler(l, nil)})
+}
+
+// RunOutput returns the writer an executor can stream its output to while
+// it runs, so the output can be followed live, or io.Discard outside a run.
+// Output streamed this way is not added to the log again when the executor
+// returns it.
+func RunOutput(ctx context.Context) io.Writer {
+	l, ok := ctx.Value(runLogKey{}).(*runLog)
+	if !ok {
+		return io.Discard
+	}
+	return l.output()
+}
+
+// startRunLog registers a log for a run of jobID that is starting, making it
+// the job's live run until it ends
+func (cm *CronManager) startRunLog(jobID string) *runLog {
+	l := newRunLog()
+	cm.mu.Lock()
+	cm.liveRuns[jobID] = l
+	cm.mu.Unlock()
+	return l
+}
+
+// endRunLog marks a run's log as ended and, unless a newer run of the job
+// has started, stops serving it as the live run
+func (cm *CronManager) endRunLog(jobID string, l *runLog) {
+	cm.mu.Lock()
+	if cm.liveRuns[jobID] == l {
+		delete(cm.liveRuns, jobID)
+	}
+	cm.mu.Unlock()
+	l.finish()
+}
+
+// RunLog returns the stored log of one of a job's runs.
+func (cm *CronManager) RunLog(jobID string, runID int64) (string, error) {
+	db := cm.currentDB()
+	if db == nil {
+		return "", fmt.Errorf("database not open")
+	}
+	var log *string
+	err := db.QueryRow(`SELECT log FROM job_runs WHERE id = ? AND job_id = ?`, runID, jobID).Scan(&log)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: job %s has no run %d", ErrRunNotFound, jobID, runID)
+	}
+	if err != nil {
+		return "", err
+	}
+	if log == nil {
+		return "", nil
+	}
+	return *log, nil
+}
+
+// StopLogFollowers ends every log stream being followed, so they don't hold
+// up server shutdown. Register it with http.Server.RegisterOnShutdown.
+func (cm *CronManager) StopLogFollowers() {
+	cm.stopFollowers.Do(func() { close(cm.followersDone) })
+}
+
+// HandleGetJobLogs serves a run's log as plain text. ?run= selects a
+// recorded run by ID; without it the job's in-progress run is served, or
+// else its most recent one. ?follow=true keeps streaming an in-progress
+// run's log until the run ends.
+func (cm *CronManager) HandleGetJobLogs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	if _, err := cm.GetJob(jobID); err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	follow := false
+	if v := r.URL.Query().Get("follow"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			apierror.Write(w, "follow must be true or false", http.StatusBadRequest)
+			return
+		}
+		follow = b
+	}
+
+	var runID int64
+	if v := r.URL.Query().Get("run"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			apierror.Write(w, "run must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		runID = n
+	} else {
+		cm.mu.RLock()
+		live := cm.liveRuns[jobID]
+		cm.mu.RUnlock()
+		if live != nil {
+			cm.writeLiveRunLog(w, r, live, follow)
+			return
+		}
+		runs, err := cm.GetJobRuns(jobID, 1)
+		if err != nil {
+			writeJobError(w, r, err)
+			return
+		}
+		if len(runs) == 0 {
+			apierror.Write(w, "job has no runs", http.StatusNotFound)
+			return
+		}
+		runID = runs[0].ID
+	}
+
+	log, err := cm.RunLog(jobID, runID)
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Run-Id", strconv.FormatInt(runID, 10))
+	io.WriteString(w, log)
+}
+
+// writeLiveRunLog writes an in-progress run's log so far and, when
+// following, whatever is added until the run ends or the client goes away.
+func (cm *CronManager) writeLiveRunLog(w http.ResponseWriter, r *http.Request, l *runLog, follow bool) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rc := http.NewResponseController(w)
+	if follow {
+		// A followed run can outlast the server's write timeout
+		rc.SetWriteDeadline(time.Time{})
+	}
+
+	offset := 0
+	for {
+		chunk, next, done, changed := l.since(offset)
+		offset = next
+		if _, err := w.Write(chunk); err != nil {
+			return
+		}
+		if !follow || done {
+			return
+		}
+		rc.Flush()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		case <-cm.followersDone:
+			return
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package cronmgr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	crondescriptor "github.com/lnquy/cron"
+	rcron "github.com/robfig/cron/v3"
+)
+
+// ScheduleList is one or more cron expressions a job runs on. In JSON it
+// accepts either a single string or an array of strings, and a single
+// schedule is written back as a plain string.
+type ScheduleList []string
+
+func (s ScheduleList) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+	if len(s) == 0 {
+		return json.Marshal("")
+	}
+	return json.Marshal([]string(s))
+}
+
+func (s *ScheduleList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = normalizeSchedules([]string{single})
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("schedule must be a string or an array of strings")
+	}
+	*s = normalizeSchedules(list)
+	return nil
+}
+
+// String joins the schedules for logging
+func (s ScheduleList) String() string {
+	return strings.Join(s, " | ")
+}
+
+// encode returns the form stored in the database: the bare expression for a
+// single schedule, or a JSON array for several.
+func (s ScheduleList) encode() string {
+	if len(s) == 1 {
+		return s[0]
+	}
+	if len(s) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal([]string(s))
+	return string(data)
+}
+
+// decodeScheduleList reverses ScheduleList.encode
+func decodeScheduleList(v string) ScheduleList {
+	if strings.HasPrefix(v, "[") {
+		var list []string
+		if err := json.Unmarshal([]byte(v), &list); err == nil {
+			return normalizeSchedules(list)
+		}
+	}
+	return normalizeSchedules([]string{v})
+}
+
+// normalizeSchedules trims whitespace and drops empty entries
+func normalizeSchedules(list []string) ScheduleList {
+	out := make(ScheduleList, 0, len(list))
+	for _, expr := range list {
+		if expr = strings.TrimSpace(expr); expr != "" {
+			out = append(out, expr)
+		}
+	}
+	return out
+}
+
+// secondsParser parses six-field expressions with a leading seconds field,
+// minutesParser standard five-field ones
+var (
+	secondsParser = rcron.NewParser(rcron.Second | rcron.Minute | rcron.Hour | rcron.Dom | rcron.Month | rcron.Dow | rcron.Descriptor)
+	minutesParser = rcron.NewParser(rcron.Minute | rcron.Hour | rcron.Dom | rcron.Month | rcron.Dow | rcron.Descriptor)
+)
+
+// useSeconds reports whether a job's schedules have a seconds field: the
+// job's useSeconds option if set, otherwise the instance default. A nil
+// config gives the default.
+func (cm *CronManager) useSeconds(config map[string]any) bool {
+	if v, ok := config[optUseSeconds].(bool); ok {
+		return v
+	}
+	return !cm.config.CronWithoutSeconds
+}
+
+// scheduleLocation returns the time zone a job's schedules are evaluated
+// in: the job's timezone option if set, otherwise the instance default
+func (cm *CronManager) scheduleLocation(config map[string]any) *time.Location {
+	if loc, _ := jobTimezone(config); loc != nil {
+		return loc
+	}
+	return cm.config.Timezone
+}
+
+// parseSchedule parses expr the way the scheduler runs it for a job with
+// config. A TZ= or CRON_TZ= prefix in the expression overrides the time
+// zone settings.
+func (cm *CronManager) parseSchedule(expr string, config map[string]any) (rcron.Schedule, error) {
+	seconds := cm.useSeconds(config)
+	expr = expandSchedulePreset(expr, seconds)
+	parser := minutesParser
+	if seconds {
+		parser = secondsParser
+	}
+	sched, err := parser.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	if spec, ok := sched.(*rcron.SpecSchedule); ok && !strings.HasPrefix(expr, "TZ=") && !strings.HasPrefix(expr, "CRON_TZ=") {
+		spec.Location = cm.scheduleLocation(config)
+	}
+	return sched, nil
+}
+
+// everyPrefix starts the scheduler's fixed-interval expressions
+const everyPrefix = "@every "
+
+// applyInterval sets the schedule of a job given as an interval to the
+// "@every" expression for it. A schedule sent with the interval must be
+// that same expression, as it is when a client saves a job it read.
+func applyInterval(job *Job) error {
+	job.Interval = strings.TrimSpace(job.Interval)
+	if job.Interval == "" {
+		return nil
+	}
+	if _, err := parseInterval(job.Interval); err != nil {
+		return fmt.Errorf("%w: interval %q: %w", ErrInvalidSchedule, job.Interval, err)
+	}
+	expr := everyPrefix + job.Interval
+	if len(job.Schedule) > 0 && !slices.Equal(job.Schedule, ScheduleList{expr}) {
+		return fmt.Errorf("%w: give either a schedule or an interval, not both", ErrInvalidSchedule)
+	}
+	job.Schedule = ScheduleList{expr}
+	return nil
+}
+
+// parseInterval parses a job interval. The scheduler works in whole
+// seconds, so shorter or fractional intervals are rejected rather than
+// silently rounded.
+func parseInterval(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < time.Second {
+		return 0, fmt.Errorf("must be at least 1s")
+	}
+	if d%time.Second != 0 {
+		return 0, fmt.Errorf("must be a whole number of seconds")
+	}
+	return d, nil
+}
+
+// describeInterval describes a fixed interval the way the cron descriptor
+// words schedules, e.g. "Every 15 minutes" or "Every 1 hour, 30 minutes".
+func describeInterval(d time.Duration) string {
+	units := []struct {
+		size time.Duration
+		name string
+	}{{time.Hour, "hour"}, {time.Minute, "minute"}, {time.Second, "second"}}
+	var parts []string
+	for _, u := range units {
+		n := d / u.size
+		d -= n * u.size
+		switch {
+		case n == 1:
+			parts = append(parts, "1 "+u.name)
+		case n > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", n, u.name))
+		}
+	}
+	if len(parts) == 1 && strings.HasPrefix(parts[0], "1 ") {
+		return "Every " + strings.TrimPrefix(parts[0], "1 ")
+	}
+	return "Every " + strings.Join(parts, ", ")
+}
+
+// describeExpr returns a human-readable description of expr read the way
+// the scheduler reads it: with a leading seconds field if seconds is set,
+// after expanding presets and dropping any TZ= or CRON_TZ= prefix. Left to
+// itself the descriptor guesses from the field count, so it would describe
+// "0 0 1 1 * 2030" as running in 2030 and a TZ= prefix as the seconds field.
+// hour24 writes times as 14:00 rather than 02:00 PM.
+func (cm *CronManager) describeExpr(expr string, seconds, hour24 bool) (string, error) {
+	expr = expandSchedulePreset(expr, seconds)
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		_, expr, _ = strings.Cut(expr, " ")
+	}
+	if interval, ok := strings.CutPrefix(expr, everyPrefix); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return "", err
+		}
+		return describeInterval(d), nil
+	}
+	fields := strings.Fields(expr)
+	if len(fields) > 0 && !strings.HasPrefix(fields[0], "@") {
+		switch {
+		case seconds && len(fields) != 6:
+			return "", fmt.Errorf("expected 6 fields starting with seconds, got %d", len(fields))
+		case !seconds && len(fields) != 5:
+			return "", fmt.Errorf("expected 5 fields without seconds, got %d", len(fields))
+		}
+		if seconds {
+			// An explicit year field stops the last field being taken for one
+			fields = append(fields, "*")
+		}
+	}
+	descriptor := &cm.cronDescriptor
+	if hour24 {
+		descriptor = &cm.cronDescriptor24h
+	}
+	return descriptor.ToDescription(strings.Join(fields, " "), crondescriptor.Locale_en)
+}
+
+// errScheduleNeverFires is reported for expressions that parse but match no
+// date, such as February 30th
+var errScheduleNeverFires = errors.New("schedule never fires")
+
+// firstRun returns when expr next fires after now for a job with config,
+// or the zero time if it never does.
+func (cm *CronManager) firstRun(expr string, config map[string]any, now time.Time) (time.Time, error) {
+	sched, err := cm.parseSchedule(expr, config)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return sched.Next(now), nil
+}
+
+// sameScheduling reports whether jobs a and b fire at the same times: the
+// same expressions in the same order once presets are expanded, with the
+// same seconds and time zone settings
+func (cm *CronManager) sameScheduling(a, b *Job) bool {
+	seconds := cm.useSeconds(a.Config)
+	if seconds != cm.useSeconds(b.Config) || cm.scheduleLocation(a.Config).String() != cm.scheduleLocation(b.Config).String() {
+		return false
+	}
+	return slices.EqualFunc(a.Schedule, b.Schedule, func(x, y string) bool {
+		return expandSchedulePreset(x, seconds) == expandSchedulePreset(y, seconds)
+	})
+}
+
+// missedRun returns the earliest time after since that any of a job's
+// schedules was due to fire, if that is before now, or the zero time if no
+// run was missed.
+func (cm *CronManager) missedRun(job *Job, since, now time.Time) time.Time {
+	var missed time.Time
+	for _, expr := range job.Schedule {
+		next, err := cm.firstRun(expr, job.Config, since)
+		if err != nil || next.IsZero() || !next.Before(now) {
+			continue
+		}
+		if missed.IsZero() || next.Before(missed) {
+			missed = next
+		}
+	}
+	return missed
+}
+
+// horizonError explains why a first run of next is suspicious: it never
+// comes, or not until after horizon. It returns nil for a sane schedule.
+func horizonError(expr string, next, now time.Time, horizon time.Duration) error {
+	if next.IsZero() {
+		return fmt.Errorf("%q: %w", expr, errScheduleNeverFires)
+	}
+	if next.Sub(now) > horizon {
+		return fmt.Errorf("%q first fires at %s, more than %s away", expr, next.Format(time.RFC3339), horizon)
+	}
+	return nil
+}
+
+// checkScheduleHorizons checks that each expression of a new or updated job
+// fires within the configured horizon, rejecting or warning according to
+// the config. Unparsable expressions are left for scheduling to report.
+func (cm *CronManager) checkScheduleHorizons(job *Job) error {
+	now := time.Now()
+	for _, expr := range job.Schedule {
+		next, err := cm.firstRun(expr, job.Config, now)
+		if err != nil {
+			continue
+		}
+		if err := horizonError(expr, next, now, cm.config.ScheduleHorizon); err != nil {
+			if cm.config.RejectDistantSchedules {
+				return fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+			}
+			slog.Warn("Schedule may never run", "job", job.Name, "error", err)
+		}
+	}
+	return nil
+}
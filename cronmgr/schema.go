@@ -0,0 +1,91 @@
+package cronmgr
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Field types reported in a ConfigField
+const (
+	FieldString   = "string"
+	FieldText     = "text" // multi-line string
+	FieldNumber   = "number"
+	FieldBool     = "boolean"
+	FieldDuration = "duration"
+	FieldTime     = "time"
+	FieldList     = "list"
+	FieldMap      = "map" // object of string values
+)
+
+// ConfigField describes one config key a job type accepts
+type ConfigField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required"`
+	Description string `json:"description,omitempty"`
+	// Common marks the manager-level options shared by every job type
+	Common bool `json:"common,omitempty"`
+	// Sensitive values are masked in API responses
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// SchemaExecutor is an optional interface for executors that declare their
+// config fields. GET /api/job-types serves the declared fields so clients
+// can build forms without hardcoding them.
+type SchemaExecutor interface {
+	ConfigSchema() []ConfigField
+}
+
+// JobTypeInfo is a registered job type and the config fields it accepts
+type JobTypeInfo struct {
+	Type   JobType       `json:"type"`
+	Fields []ConfigField `json:"fields"`
+}
+
+// commonConfigFields are the manager-level options every job type accepts
+var commonConfigFields = []ConfigField{
+	{Name: optJitter, Type: FieldDuration, Common: true, Description: "Delay each run by a random duration up to this"},
+	{Name: optMaxRuns, Type: FieldNumber, Common: true, Description: "Disable the job after this many runs"},
+	{Name: optNotBefore, Type: FieldTime, Common: true, Description: "Don't run before this RFC3339 time"},
+	{Name: optNotAfter, Type: FieldTime, Common: true, Description: "Disable the job after this RFC3339 time"},
+	{Name: optDependsOn, Type: FieldList, Common: true, Description: "Job IDs that must have succeeded recently"},
+	{Name: optDependsOnWindow, Type: FieldDuration, Common: true, Description: "How recent a dependency's success must be (default 24h)"},
+	{Name: optRunOnStart, Type: FieldBool, Common: true, Description: "Run once when the server starts, as well as on schedule"},
+	{Name: optMisfire, Type: FieldString, Common: true, Description: "\"runOnce\" to catch up once on startup if a run was missed while the server was down, or \"skip\" (default)"},
+	{Name: optTimezone, Type: FieldString, Common: true, Description: "IANA time zone the schedule is evaluated in (default DEFAULT_TIMEZONE)"},
+	{Name: optUseSeconds, Type: FieldBool, Common: true, Description: "Whether the schedule has a leading seconds field (default CRON_USE_SECONDS)"},
+	{Name: optUploadOutputTo, Type: FieldString, Common: true, Description: "Storage folder to upload each run's output to (job types that capture output)"},
+	{Name: optPingOnSuccessURL, Type: FieldString, Common: true, Sensitive: true, Description: "URL requested after each successful run"},
+}
+
+// JobTypes lists the registered job types, sorted by name, with each type's
+// own fields followed by the common options.
+func (cm *CronManager) JobTypes() []JobTypeInfo {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	types := make([]JobTypeInfo, 0, len(cm.executors))
+	for jobType, executor := range cm.executors {
+		var fields []ConfigField
+		if s, ok := executor.(SchemaExecutor); ok {
+			fields = append(fields, s.ConfigSchema()...)
+		}
+		fields = append(fields, commonConfigFields...)
+		types = append(types, JobTypeInfo{Type: jobType, Fields: fields})
+	}
+	slices.SortFunc(types, func(a, b JobTypeInfo) int {
+		return strings.Compare(string(a.Type), string(b.Type))
+	})
+	return types
+}
+
+// checkRequired reports the first required field missing from config
+func checkRequired(fields []ConfigField, config map[string]any) error {
+	for _, f := range fields {
+		if _, ok := config[f.Name]; f.Required && !ok {
+			return fmt.Errorf("'%s' field is required", f.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package cronmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrSecretNotFound is returned by a SecretResolver for unknown keys
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretResolver looks up secrets referenced from job config as
+// "${secret:key}". References are resolved just before each run, so the
+// secret values themselves are never stored in the database or backups.
+type SecretResolver interface {
+	Resolve(key string) (string, error)
+}
+
+// secretRefPattern matches a secret reference inside a config string
+var secretRefPattern = regexp.MustCompile(`\$\{secret:([A-Za-z0-9_.-]+)\}`)
+
+// secretKeyPattern restricts secret keys so file lookups stay inside the
+// secrets directory
+var secretKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// EnvSecretResolver reads secrets from environment variables named Prefix
+// followed by the upper-cased key, so "smtp_pass" with prefix
+// "CRONOS_SECRET_" reads CRONOS_SECRET_SMTP_PASS.
+type EnvSecretResolver struct {
+	Prefix string
+}
+
+func (e EnvSecretResolver) Resolve(key string) (string, error) {
+	name := e.Prefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, key)
+	}
+	return v, nil
+}
+
+// FileSecretResolver reads each secret from a file named after its key in
+// Dir, such as a mounted Kubernetes or Docker secrets directory. A trailing
+// newline is trimmed.
+type FileSecretResolver struct {
+	Dir string
+}
+
+func (f FileSecretResolver) Resolve(key string) (string, error) {
+	if !secretKeyPattern.MatchString(key) {
+		return "", fmt.Errorf("invalid secret key %q", key)
+	}
+	data, err := os.ReadFile(filepath.Join(f.Dir, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, key)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read secret %s: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveSecrets returns a copy of config with every secret reference in
+// its strings, nested objects and lists replaced by the secret's value.
+func (cm *CronManager) resolveSecrets(config map[string]any) (map[string]any, error) {
+	resolved, err := cm.resolveValue(config)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := resolved.(map[string]any)
+	return m, nil
+}
+
+// unresolvedConfigKey is the context key carrying a run's config as stored,
+// with its secret references unresolved
+type unresolvedConfigKey struct{}
+
+// withUnresolvedConfig returns a context carrying the stored config of the
+// job being run, for executors to log from
+func withUnresolvedConfig(ctx context.Context, config map[string]any) context.Context {
+	return context.WithValue(ctx, unresolvedConfigKey{}, config)
+}
+
+// loggableConfig returns the config an executor may log or echo: the
+// stored config, whose secret references show as "${secret:key}", if ctx
+// carries it, or else config itself.
+func loggableConfig(ctx context.Context, config map[string]any) map[string]any {
+	if stored, ok := ctx.Value(unresolvedConfigKey{}).(map[string]any); ok {
+		return stored
+	}
+	return config
+}
+
+func (cm *CronManager) resolveValue(v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return cm.resolveString(v)
+	case map[string]any:
+		if v == nil {
+			return v, nil
+		}
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			r, err := cm.resolveValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			r, err := cm.resolveValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+func (cm *CronManager) resolveString(s string) (string, error) {
+	if !strings.Contains(s, "${secret:") {
+		return s, nil
+	}
+	if cm.config.SecretResolver == nil {
+		return "", fmt.Errorf("config references a secret but no secret store is configured")
+	}
+	var resolveErr error
+	out := secretRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		key := secretRefPattern.FindStringSubmatch(ref)[1]
+		v, err := cm.config.SecretResolver.Resolve(key)
+		if err != nil && resolveErr == nil {
+			resolveErr = err
+		}
+		return v
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return out, nil
+}
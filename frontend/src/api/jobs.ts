@@ -1,12 +1,23 @@
-import type { Job } from "../types/job";
+import type { DryRunResult, Job, JobTypeInfo } from "../types/job";
 
 // Use relative URL in development (proxied by Vite) or when served from same origin
 // Fall back to absolute URL for production when frontend and backend are separate
 export const API_BASE = "/api";
 
+// Errors come back as {"error": "...", "code": N}; prefer the server's message.
+const apiError = async (res: Response, fallback: string): Promise<Error> => {
+  try {
+    const body = await res.json();
+    if (body && typeof body.error === "string") return new Error(body.error);
+  } catch {
+    // not a JSON error body
+  }
+  return new Error(`${fallback} (HTTP ${res.status})`);
+};
+
 export const fetchJobs = async (): Promise<Job[]> => {
   const res = await fetch(`${API_BASE}/jobs`);
-  if (!res.ok) throw new Error("Failed to fetch jobs");
+  if (!res.ok) throw await apiError(res, "Failed to fetch jobs");
   return res.json();
 };
 
@@ -16,13 +27,13 @@ export const createJob = async (job: Partial<Job>) => {
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(job),
   });
-  if (!res.ok) throw new Error("Failed to create job");
+  if (!res.ok) throw await apiError(res, "Failed to create job");
   return res.json();
 };
 
 export const findJobByName = async (name: string) => {
   const res = await fetch(`${API_BASE}/jobs?name=${name}`);
-  if (!res.ok) throw new Error("Failed to find job");
+  if (!res.ok) throw await apiError(res, "Failed to find job");
   return res.json();
 };
 
@@ -35,7 +46,36 @@ export const updateJob = async ({
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(job),
   });
-  if (!res.ok) throw new Error("Failed to update job");
+  if (!res.ok) throw await apiError(res, "Failed to update job");
+  return res.json();
+};
+
+// Renames a job without touching its schedule or run history
+export const renameJob = async ({
+  id,
+  name,
+  version,
+}: { id: string; name: string; version?: number }): Promise<Job> => {
+  const res = await fetch(`${API_BASE}/jobs/${id}`, {
+    method: "PATCH",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ name, version }),
+  });
+  if (!res.ok) throw await apiError(res, "Failed to rename job");
+  return res.json();
+};
+
+export const toggleJob = async (
+  id: string,
+): Promise<{ id: string; enabled: boolean; nextRun?: string; version: number }> => {
+  const res = await fetch(`${API_BASE}/jobs/${id}/toggle`, { method: "POST" });
+  if (!res.ok) throw await apiError(res, "Failed to toggle job");
+  return res.json();
+};
+
+export const dryRunJob = async (id: string): Promise<DryRunResult> => {
+  const res = await fetch(`${API_BASE}/jobs/${id}/dry-run`, { method: "POST" });
+  if (!res.ok) throw await apiError(res, "Failed to dry run job");
   return res.json();
 };
 
@@ -43,18 +83,39 @@ export const deleteJob = async (id: string) => {
   const res = await fetch(`${API_BASE}/jobs/${id}`, {
     method: "DELETE",
   });
-  if (!res.ok) throw new Error("Failed to delete job");
+  if (!res.ok) throw await apiError(res, "Failed to delete job");
 };
 
-export const describeCron = async (schedule: string): Promise<{ description: string }> => {
+export const fetchJobTypes = async (): Promise<JobTypeInfo[]> => {
+  const res = await fetch(`${API_BASE}/job-types`);
+  if (!res.ok) throw await apiError(res, "Failed to fetch job types");
+  return res.json();
+};
+
+export const describeCron = async (
+  schedule: string,
+  hasSeconds?: boolean,
+  use24HourTime?: boolean,
+): Promise<{ description: string; hasSeconds: boolean; use24HourTime: boolean; nextRun?: string; warning?: string }> => {
   const res = await fetch(`${API_BASE}/describe-cron`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
-    body: JSON.stringify({ schedule }),
+    body: JSON.stringify({ schedule, hasSeconds, use24HourTime }),
   });
-  if (!res.ok) {
-    const text = await res.text();
-    throw new Error(text || `HTTP ${res.status}`);
-  }
+  if (!res.ok) throw await apiError(res, "Failed to describe schedule");
+  return res.json();
+};
+
+export const describeCronBatch = async (
+  schedules: string[],
+  hasSeconds?: boolean,
+  use24HourTime?: boolean,
+): Promise<{ schedule: string; hasSeconds: boolean; use24HourTime: boolean; description?: string; nextRun?: string; warning?: string; error?: string }[]> => {
+  const res = await fetch(`${API_BASE}/describe-cron/batch`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ schedules, hasSeconds, use24HourTime }),
+  });
+  if (!res.ok) throw await apiError(res, "Failed to describe schedules");
   return res.json();
 };
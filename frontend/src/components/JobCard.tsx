@@ -1,6 +1,6 @@
 import { useMutation, useQueryClient } from "@tanstack/react-query";
-import { Calendar, Clock, Edit2, Power, PowerOff, Trash2 } from "lucide-react";
-import { deleteJob, updateJob } from "../api/jobs";
+import { Calendar, Clock, Edit2, Play, Power, PowerOff, Trash2 } from "lucide-react";
+import { deleteJob, dryRunJob, toggleJob } from "../api/jobs";
 import type { Job } from "../types/job";
 
 type Props = {
@@ -17,12 +17,17 @@ export default function JobCard({ job, onEdit }: Props) {
 	});
 
 	const toggleMutation = useMutation({
-		mutationFn: updateJob,
+		mutationFn: toggleJob,
 		onSuccess: () => queryClient.invalidateQueries({ queryKey: ["jobs"] }),
 	});
 
+	// A dry run changes nothing, so the job list needn't be refreshed
+	const dryRunMutation = useMutation({
+		mutationFn: dryRunJob,
+	});
+
 	const handleToggle = () => {
-		toggleMutation.mutate({ ...job, enabled: !job.enabled });
+		toggleMutation.mutate(job.id);
 	};
 
 	const getTypeColor = (type: string) => {
@@ -35,6 +40,13 @@ export default function JobCard({ job, onEdit }: Props) {
 		return colors[type] || "bg-gray-100 text-gray-800";
 	};
 
+	const statusColors: Record<string, string> = {
+		paused: "bg-yellow-100 text-yellow-800",
+		disabled: "bg-gray-100 text-gray-700",
+		completed: "bg-teal-100 text-teal-800",
+		error: "bg-red-100 text-red-800",
+	};
+
 	const formatDate = (date?: string | number | null) => {
 		if (date === undefined || date === null || date === "") return "Never";
 
@@ -69,7 +81,15 @@ export default function JobCard({ job, onEdit }: Props) {
 						>
 							{job.type}
 						</span>
+						{job.status && job.status !== "active" ? (
+							<span className={`px-2 py-1 rounded-full text-xs font-medium ${statusColors[job.status] || "bg-gray-100 text-gray-700"}`}>
+								{job.status}
+							</span>
+						) : null}
 					</div>
+					{job.description ? (
+						<p className="text-sm text-gray-700 mb-2 whitespace-pre-line">{job.description}</p>
+					) : null}
 					<p className="text-sm text-gray-600 font-mono bg-gray-50 px-2 py-1 rounded inline-block">
 						{job.schedule}
 					</p>
@@ -87,6 +107,15 @@ export default function JobCard({ job, onEdit }: Props) {
 					>
 						{job.enabled ? <Power size={18} className="icon dark:text-red-500" /> : <PowerOff size={18} className="icon dark:text-red-500" />}
 					</button>
+					<button
+						type="button"
+						onClick={() => dryRunMutation.mutate(job.id)}
+						disabled={dryRunMutation.isPending}
+						className="p-2 bg-yellow-100 text-yellow-700 rounded-lg hover:bg-yellow-200 transition-colors disabled:opacity-50"
+						title="Dry run"
+					>
+						<Play size={18} className="icon dark:text-red-500" />
+					</button>
 					<button
 						type="button"
 						onClick={() => onEdit(job)}
@@ -117,6 +146,24 @@ export default function JobCard({ job, onEdit }: Props) {
 				</div>
 			</div>
 
+			{dryRunMutation.error ? (
+				<p className="mt-4 text-sm text-red-700">{dryRunMutation.error.message}</p>
+			) : null}
+			{dryRunMutation.data ? (
+				<div className="mt-4 pt-4 border-t border-gray-200 text-sm">
+					<p className={`text-xs font-medium mb-2 ${dryRunMutation.data.success ? "text-green-700" : "text-red-700"}`}>
+						Dry run {dryRunMutation.data.success ? "succeeded" : "failed"}
+					</p>
+					{dryRunMutation.data.output ? (
+						<pre className="text-gray-800 bg-gray-50 px-2 py-1 rounded whitespace-pre-wrap">{dryRunMutation.data.output}</pre>
+					) : null}
+					{dryRunMutation.data.error ? <p className="text-red-700 mt-1">{dryRunMutation.data.error}</p> : null}
+					{dryRunMutation.data.warnings?.map((w) => (
+						<p key={w} className="text-yellow-700 mt-1">{w}</p>
+					))}
+				</div>
+			) : null}
+
 			<div className="mt-4 pt-4 border-t border-gray-200">
 				<p className="text-xs font-medium text-gray-500 mb-2">Configuration:</p>
 				<div className="text-sm text-gray-700 space-y-1">
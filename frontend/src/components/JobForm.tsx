@@ -1,15 +1,17 @@
-import { useMutation, useQueryClient } from "@tanstack/react-query";
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
 import type React from "react";
 import { useId, useState, useEffect, useRef } from "react";
 import { Sliders, ChevronDown, ChevronUp, X } from "lucide-react";
-import { createJob, updateJob, describeCron } from "../api/jobs";
-import type { Job } from "../types/job";
+import { createJob, updateJob, describeCron, fetchJobTypes } from "../api/jobs";
+import type { Job, JobTypeInfo } from "../types/job";
 
 type JobFormState = {
 	id?: string;
 	name: string;
+	description: string;
 	type: Job["type"];
 	schedule: string;
+	interval: string;
 	enabled: boolean;
 	config: Record<string, string>;
 };
@@ -29,15 +31,19 @@ export default function JobForm({ job, onClose }: Props) {
 			? {
 					id: job.id,
 					name: job.name,
+					description: job.description ?? "",
 					type: job.type,
 					schedule: job.schedule,
+					interval: job.interval ?? "",
 					enabled: job.enabled,
 					config: (job.config || {}) as Record<string, string>,
 				}
 			: {
 					name: "",
+					description: "",
 					type: "email",
 					schedule: defaultSchedule,
+					interval: "",
 					enabled: true,
 					config: {} as Record<string, string>,
 				},
@@ -155,7 +161,8 @@ export default function JobForm({ job, onClose }: Props) {
 			return schedule;
 		};
 
-		const utcSchedule = convertScheduleToUTC(formData.schedule);
+		// An interval replaces the cron schedule; the server derives it
+		const utcSchedule = formData.interval.trim() ? undefined : convertScheduleToUTC(formData.schedule);
 
 		if (job) {
 			updateMutation.mutate({ ...(formData as Partial<Job>), id: job.id, schedule: utcSchedule });
@@ -169,7 +176,14 @@ export default function JobForm({ job, onClose }: Props) {
 		setFormData((prev) => ({ ...prev, config: { ...prev.config, [key]: value } }));
 	};
 
-	const jobTypes = ["email", "sync", "backup", "custom"];
+	// The server's job type schema is authoritative; the built-in list covers
+	// the moment before it loads.
+	const { data: jobTypeInfo } = useQuery<JobTypeInfo[], Error>({
+		queryKey: ["job-types"],
+		queryFn: fetchJobTypes,
+		staleTime: Infinity,
+	});
+	const jobTypes = jobTypeInfo?.map((t) => t.type) ?? ["email", "sync", "backup", "custom"];
 
 	const renderConfigFields = () => {
 		switch (formData.type) {
@@ -284,8 +298,37 @@ export default function JobForm({ job, onClose }: Props) {
 						required
 					/>
 				);
-			default:
-				return null;
+			default: {
+				// Types without a dedicated form get one input per declared field
+				const fields = jobTypeInfo?.find((t) => t.type === formData.type)?.fields ?? [];
+				return (
+					<>
+						{fields.filter((f) => !f.common).map((f) =>
+							f.type === "text" ? (
+								<textarea
+									key={f.name}
+									placeholder={f.description ?? f.name}
+									value={formData.config[f.name] || ""}
+									onChange={(e) => updateConfig(f.name, e.target.value)}
+									className="w-full px-3 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent font-mono text-sm"
+									rows={4}
+									required={f.required}
+								/>
+							) : (
+								<input
+									key={f.name}
+									type="text"
+									placeholder={f.description ?? f.name}
+									value={formData.config[f.name] || ""}
+									onChange={(e) => updateConfig(f.name, e.target.value)}
+									className="w-full px-3 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent"
+									required={f.required}
+								/>
+							),
+						)}
+					</>
+				);
+			}
 		}
 	};
 
@@ -309,6 +352,17 @@ export default function JobForm({ job, onClose }: Props) {
 						/>
 					</div>
 
+					<div>
+						<label htmlFor={`${uid}-description`} className="block text-sm font-medium text-gray-700 mb-1">Description</label>
+						<textarea
+							id={`${uid}-description`}
+							value={formData.description}
+							onChange={(e) => setFormData((prev) => ({ ...prev, description: e.target.value }))}
+							rows={2}
+							className="w-full px-3 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent"
+						/>
+					</div>
+
 					<div>
 						<label htmlFor={`${uid}-type`} className="block text-sm font-medium text-gray-700 mb-1">Job Type</label>
 						<select
@@ -356,6 +410,18 @@ export default function JobForm({ job, onClose }: Props) {
 							)}
 						</div>
 
+						<div className="mt-2">
+							<label htmlFor={`${uid}-interval`} className="text-xs text-gray-500">Or run at a fixed interval instead (e.g. 15m, 2h)</label>
+							<input
+								id={`${uid}-interval`}
+								type="text"
+								value={formData.interval}
+								onChange={(e) => setFormData((prev) => ({ ...prev, interval: e.target.value }))}
+								placeholder="15m"
+								className="w-full px-2 py-1 border border-gray-300 rounded font-mono text-sm"
+							/>
+						</div>
+
 						{showCronFields && (
 							<div className="grid grid-cols-3 gap-2 mt-3">
 								{["seconds", "minutes", "hours", "day", "month", "weekday"].map((label, idx) => {
@@ -1,11 +1,50 @@
+export type ConfigField = {
+	name: string;
+	type: "string" | "text" | "number" | "duration" | "time" | "list" | string;
+	required: boolean;
+	description?: string;
+	common?: boolean;
+	sensitive?: boolean;
+};
+
+export type JobTypeInfo = {
+	type: string;
+	fields: ConfigField[];
+};
+
+export type DryRunResult = {
+	jobId: string;
+	success: boolean;
+	output?: string;
+	error?: string;
+	log?: string;
+	warnings?: string[];
+};
+
 export type Job = {
 	id: string;
 	name: string;
+	description?: string;
 	type: "email" | "sync" | "backup" | "custom" | string;
-	schedule: string;
+	schedule: string | string[];
+	interval?: string;
 	scheduleDesc: string;
 	enabled: boolean;
+	status?: "active" | "paused" | "disabled" | "completed" | "error" | string;
 	lastRun?: string | null;
 	nextRun?: string | null;
 	config?: Record<string, string>;
+	version?: number;
+	tags?: string[];
+	createdBy?: string;
+	createdAt?: string | null;
+	updatedAt?: string | null;
+	secondsUntilNextRun?: number;
+	overdue?: boolean;
+	lastError?: string;
+	lastErrorAt?: string | null;
+	successCount?: number;
+	failureCount?: number;
+	lastDriftMs?: number;
+	loadError?: string;
 };
@@ -3,6 +3,7 @@ module tapasrm.dev/cron-ui
 go 1.23.5
 
 require (
+	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.19.1
 	github.com/Azure/azure-sdk-for-go/sdk/storage/azblob v1.6.3
 	github.com/gorilla/mux v1.8.1
 	github.com/lnquy/cron v1.1.1
@@ -11,7 +12,6 @@ require (
 )
 
 require (
-	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.19.1 // indirect
 	github.com/Azure/azure-sdk-for-go/sdk/internal v1.11.2 // indirect
 	github.com/google/uuid v1.6.0
 	golang.org/x/net v0.43.0 // indirect
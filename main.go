@@ -2,37 +2,74 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
+	_ "time/tzdata" // DEFAULT_TIMEZONE and job time zones on images without zoneinfo
 
 	"github.com/gorilla/mux"
+	"tapasrm.dev/cron-ui/apierror"
 	"tapasrm.dev/cron-ui/backup"
 	"tapasrm.dev/cron-ui/cronmgr"
+	"tapasrm.dev/cron-ui/requestid"
 	"tapasrm.dev/cron-ui/storage"
 )
 
+// Build information, set at build time with
+// -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=..."
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// startTime is when the process started, for reporting uptime
+var startTime = time.Now()
+
 func setupLogger() {
 	// Use JSON handler for structured logs (better for production)
 	// Or use TextHandler for human-readable logs (better for development)
 	useJSON := os.Getenv("LOG_FORMAT") == "json"
 
+	// LOG_LEVEL is debug, info, warn or error; LOG_SOURCE=false drops the
+	// file:line attribute from every record
+	var level slog.Level
+	levelErr := level.UnmarshalText([]byte(envOr("LOG_LEVEL", "info")))
+	if levelErr != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{
+		Level:     level,
+		AddSource: os.Getenv("LOG_SOURCE") != "false",
+	}
+
 	var handler slog.Handler
 	if useJSON {
-		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level:     slog.LevelInfo,
-			AddSource: true,
-		})
+		handler = slog.NewJSONHandler(os.Stdout, opts)
 	} else {
-		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-			Level:     slog.LevelInfo,
-			AddSource: true,
-		})
+		handler = slog.NewTextHandler(os.Stdout, opts)
 	}
 
+	// Tag log lines written while serving a request with its ID
+	handler = requestid.NewLogHandler(handler)
+
 	logger := slog.New(handler)
 	slog.SetDefault(logger)
+
+	if levelErr != nil {
+		slog.Warn("Invalid LOG_LEVEL, using info", "value", os.Getenv("LOG_LEVEL"))
+	}
 }
 
 func main() {
@@ -50,83 +87,484 @@ func main() {
 
 	var blobServer *storage.BlobServer
 	var backupStore storage.Storage
+	azureRetry := storage.DefaultRetryOptions
 
 	if hasAzureStorage {
+		retries, err := envInt("AZURE_RETRIES", storage.DefaultRetryOptions.Retries)
+		if err != nil {
+			slog.Error("Invalid Azure retry count", "error", err)
+			os.Exit(1)
+		}
+		retryDelay, err := envDuration("AZURE_RETRY_DELAY", storage.DefaultRetryOptions.Delay)
+		if err != nil {
+			slog.Error("Invalid Azure retry delay", "error", err)
+			os.Exit(1)
+		}
+		azureRetry = storage.RetryOptions{Retries: retries, Delay: retryDelay}
+
 		// Initialize Blob Storages
-		assetsStore, err := storage.NewAzureBlobStorage(account, key, assetsContainer, cdnBase)
+		assetsStore, err := storage.NewAzureBlobStorage(account, key, assetsContainer, cdnBase, azureRetry)
 		if err != nil {
 			slog.Error("Failed to initialize assets storage", "error", err)
 			os.Exit(1)
 		}
-		backupStore, err = storage.NewAzureBlobStorage(account, key, backupContainer, "")
+		backupStore, err = storage.NewAzureBlobStorage(account, key, backupContainer, "", azureRetry)
 		if err != nil {
 			slog.Error("Failed to initialize backup storage", "error", err)
 			os.Exit(1)
 		}
 
+		uploadTimeout, err := envDuration("HTTP_UPLOAD_TIMEOUT", 10*time.Minute)
+		if err != nil {
+			slog.Error("Invalid upload timeout", "error", err)
+			os.Exit(1)
+		}
+		uploadMinRate, err := envInt("HTTP_UPLOAD_MIN_RATE", 1<<20)
+		if err != nil {
+			slog.Error("Invalid upload minimum rate", "error", err)
+			os.Exit(1)
+		}
+		listTimeout, err := envDuration("STORAGE_LIST_TIMEOUT", storage.DefaultOpTimeout)
+		if err != nil {
+			slog.Error("Invalid storage list timeout", "error", err)
+			os.Exit(1)
+		}
+		downloadTimeout, err := envDuration("STORAGE_DOWNLOAD_TIMEOUT", storage.DefaultOpTimeout)
+		if err != nil {
+			slog.Error("Invalid storage download timeout", "error", err)
+			os.Exit(1)
+		}
+		deleteTimeout, err := envDuration("STORAGE_DELETE_TIMEOUT", storage.DefaultOpTimeout)
+		if err != nil {
+			slog.Error("Invalid storage delete timeout", "error", err)
+			os.Exit(1)
+		}
 		blobServer = &storage.BlobServer{
-			Assets:  assetsStore,
-			Backups: backupStore,
+			Assets:          assetsStore,
+			Backups:         backupStore,
+			UploadTimeout:   uploadTimeout,
+			UploadMinRate:   int64(uploadMinRate),
+			ListTimeout:     listTimeout,
+			DownloadTimeout: downloadTimeout,
+			DeleteTimeout:   deleteTimeout,
 		}
 		slog.Info("Azure blob storage initialized", "assets_container", assetsContainer, "backup_container", backupContainer)
 	} else {
 		slog.Info("Azure storage not configured, running with local SQLite only", "hint", "Set AZURE_STORAGE_ACCOUNT, AZURE_STORAGE_KEY, ASSETS_CONTAINER, and BACKUP_CONTAINER to enable blob storage")
 	}
 
-	db_path := "cron_jobs.db"
-	blobName := "cronos_backups/cron_jobs.db"
+	cfg := cronmgr.Config{
+		DBPath:         envOr("DB_PATH", cronmgr.DefaultDBPath),
+		BackupBlobName: envOr("BACKUP_BLOB_NAME", cronmgr.DefaultBackupBlobName),
 
-	// Only restore from backup if backup storage is available
-	if backupStore != nil {
-		if err := backup.RestoreSQLite(ctx, db_path, blobName, backupStore); err != nil {
+		CustomJobAllowedCommands: splitList(os.Getenv("CUSTOM_JOB_ALLOWED_COMMANDS")),
+		CustomJobAllowShell:      os.Getenv("CUSTOM_JOB_ALLOW_SHELL") == "true",
+		QueryJobAllowedDSNs:      splitList(os.Getenv("QUERY_JOB_ALLOWED_DSNS")),
+		QueryJobAllowedDrivers:   splitList(os.Getenv("QUERY_JOB_ALLOWED_DRIVERS")),
+	}
+	maxJobs, err := envInt("MAX_JOBS", 0)
+	if err != nil {
+		slog.Error("Invalid job limit", "error", err)
+		os.Exit(1)
+	}
+	cfg.MaxJobs = maxJobs
+	maxConcurrentRuns, err := envInt("MAX_CONCURRENT_RUNS", 0)
+	if err != nil {
+		slog.Error("Invalid concurrent run limit", "error", err)
+		os.Exit(1)
+	}
+	cfg.MaxConcurrentRuns = maxConcurrentRuns
+	deleteRetention, err := envDuration("JOB_DELETE_RETENTION", cronmgr.DefaultDeleteRetention)
+	if err != nil {
+		slog.Error("Invalid job delete retention", "error", err)
+		os.Exit(1)
+	}
+	cfg.DeleteRetention = deleteRetention
+	scheduleHorizon, err := envDuration("SCHEDULE_HORIZON", cronmgr.DefaultScheduleHorizon)
+	if err != nil {
+		slog.Error("Invalid schedule horizon", "error", err)
+		os.Exit(1)
+	}
+	cfg.ScheduleHorizon = scheduleHorizon
+	cfg.RejectDistantSchedules = os.Getenv("REJECT_DISTANT_SCHEDULES") == "true"
+	if tz := os.Getenv("DEFAULT_TIMEZONE"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			slog.Error("Invalid default timezone", "error", err)
+			os.Exit(1)
+		}
+		cfg.Timezone = loc
+	}
+	cfg.CronWithoutSeconds = os.Getenv("CRON_USE_SECONDS") == "false"
+	cfg.Use24HourTime = os.Getenv("SCHEDULE_24_HOUR_TIME") == "true"
+	cfg.SkipJobLoad = os.Getenv("LOAD_JOBS") == "false"
+	cfg.RequireUniqueNames = os.Getenv("REQUIRE_UNIQUE_NAMES") == "true"
+	cfg.ReadOnly = os.Getenv("READ_ONLY") == "true"
+	cfg.SQLiteJournalMode = os.Getenv("SQLITE_JOURNAL_MODE")
+	cfg.SQLiteSynchronous = os.Getenv("SQLITE_SYNCHRONOUS")
+	maxRequestBytes, err := envInt("MAX_REQUEST_BYTES", cronmgr.DefaultMaxRequestBytes)
+	if err != nil {
+		slog.Error("Invalid max request size", "error", err)
+		os.Exit(1)
+	}
+	cfg.MaxRequestBytes = int64(maxRequestBytes)
+	backupStaleFactor, err := envInt("BACKUP_STALE_FACTOR", cronmgr.DefaultBackupStaleFactor)
+	if err != nil {
+		slog.Error("Invalid backup stale factor", "error", err)
+		os.Exit(1)
+	}
+	cfg.BackupStaleFactor = backupStaleFactor
+	shutdownTimeout, err := envDuration("SHUTDOWN_TIMEOUT", cronmgr.DefaultShutdownTimeout)
+	if err != nil {
+		slog.Error("Invalid shutdown timeout", "error", err)
+		os.Exit(1)
+	}
+	cfg.ShutdownTimeout = shutdownTimeout
+	if blobServer != nil {
+		cfg.OutputStore = blobServer.Assets
+		blobServer.ProtectedNames = []string{cfg.BackupBlobName, backup.ChecksumBlobName(cfg.BackupBlobName)}
+	}
+	cfg.RevealToken = os.Getenv("SECRETS_REVEAL_TOKEN")
+	cfg.AdminToken = os.Getenv("ADMIN_TOKEN")
+	if dir := os.Getenv("SECRETS_DIR"); dir != "" {
+		cfg.SecretResolver = cronmgr.FileSecretResolver{Dir: dir}
+	} else {
+		cfg.SecretResolver = cronmgr.EnvSecretResolver{Prefix: envOr("SECRETS_ENV_PREFIX", "CRONOS_SECRET_")}
+	}
+	if lockContainer := os.Getenv("CLUSTER_LOCK_CONTAINER"); lockContainer != "" {
+		if !hasAzureStorage {
+			slog.Error("CLUSTER_LOCK_CONTAINER needs Azure storage to be configured")
+			os.Exit(1)
+		}
+		lockStore, err := storage.NewAzureBlobStorage(account, key, lockContainer, "", azureRetry)
+		if err != nil {
+			slog.Error("Failed to initialize run lock storage", "error", err)
+			os.Exit(1)
+		}
+		owner, _ := os.Hostname()
+		cfg.RunLocker = cronmgr.StorageRunLocker{Store: lockStore, Owner: owner}
+		slog.Info("Cluster run locks enabled", "container", lockContainer)
+	}
+	if cfg.CustomJobAllowShell && !slices.Contains(cfg.CustomJobAllowedCommands, cronmgr.AnyCommand) {
+		slog.Error("CUSTOM_JOB_ALLOW_SHELL can't be combined with a list of allowed commands, since the shell can run anything", "hint", "Set CUSTOM_JOB_ALLOWED_COMMANDS to * or unset CUSTOM_JOB_ALLOW_SHELL")
+		os.Exit(1)
+	}
+	if len(cfg.CustomJobAllowedCommands) == 0 {
+		slog.Info("Custom jobs are disabled", "hint", "Set CUSTOM_JOB_ALLOWED_COMMANDS to the binaries custom jobs may run")
+	} else if slices.Contains(cfg.CustomJobAllowedCommands, cronmgr.AnyCommand) {
+		slog.Warn("Custom job commands are unrestricted", "hint", "Set CUSTOM_JOB_ALLOWED_COMMANDS to the binaries custom jobs may run instead of *")
+	}
+	if len(cfg.QueryJobAllowedDSNs) == 0 {
+		slog.Info("Query jobs are disabled", "hint", "Set QUERY_JOB_ALLOWED_DSNS to the data sources query jobs may use")
+	}
+
+	// Only restore from backup if backup storage is available, and not when
+	// the restored jobs wouldn't be loaded anyway
+	if backupStore != nil && !cfg.SkipJobLoad {
+		if err := backup.RestoreSQLite(ctx, cfg.DBPath, cfg.BackupBlobName, backupStore); errors.Is(err, storage.ErrNotFound) {
 			slog.Info("No existing backup found, starting fresh", "error", err)
+		} else if err != nil {
+			slog.Warn("Restore from backup failed, keeping local database", "error", err)
 		}
 	}
 
-	manager := cronmgr.NewCronManager()
+	manager := cronmgr.NewCronManager(cfg)
 	manager.Start()
 
+	syncInterval, err := envDuration("SYNC_INTERVAL", 30*time.Second)
+	if err != nil {
+		slog.Error("Invalid sync interval", "error", err)
+		os.Exit(1)
+	}
+	backupInterval, err := envDuration("BACKUP_INTERVAL", 1*time.Hour)
+	if err != nil {
+		slog.Error("Invalid backup interval", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Background sync configured", "sync_interval", syncInterval, "backup_interval", backupInterval, "backup_enabled", backupStore != nil)
+
 	// Start background sync - pass nil for backupStore if not configured (backup will be disabled)
-	manager.StartBackgroundSync(db_path, 30*time.Second, 1*time.Hour, blobName, backupStore)
+	manager.StartBackgroundSync(syncInterval, backupInterval, backupStore)
+
+	historyMaxAge, err := envDuration("RUN_HISTORY_MAX_AGE", 30*24*time.Hour)
+	if err != nil {
+		slog.Error("Invalid run history max age", "error", err)
+		os.Exit(1)
+	}
+	historyMaxRuns, err := envInt("RUN_HISTORY_MAX_PER_JOB", 1000)
+	if err != nil {
+		slog.Error("Invalid run history limit", "error", err)
+		os.Exit(1)
+	}
+	manager.StartRunHistoryPruner(1*time.Hour, historyMaxAge, historyMaxRuns)
+
+	reconcileInterval, err := envDuration("RECONCILE_INTERVAL", 5*time.Minute)
+	if err != nil {
+		slog.Error("Invalid reconcile interval", "error", err)
+		os.Exit(1)
+	}
+	manager.StartReconciler(reconcileInterval)
+	manager.StartDeletedJobPurger(1 * time.Hour)
 	defer manager.Stop()
 
+	disabled, err := disabledFeatures(os.Getenv("DISABLED_FEATURES"))
+	if err != nil {
+		slog.Error("Invalid disabled features", "error", err)
+		os.Exit(1)
+	}
+	if len(disabled) > 0 {
+		slog.Info("Features disabled", "features", os.Getenv("DISABLED_FEATURES"))
+	}
+
+	// Changes are refused with 409 while this instance is a read-only standby
+	writable := manager.RequireWritable
+
 	router := mux.NewRouter()
 	router.HandleFunc("/api/jobs", manager.HandleGetJobs).Methods("GET")
-	router.HandleFunc("/api/jobs", manager.HandleCreateJob).Methods("POST")
+	router.HandleFunc("/api/jobs", writable(manager.HandleCreateJob)).Methods("POST")
+	router.HandleFunc("/api/jobs", writable(manager.HandleDeleteAllJobs)).Methods("DELETE")
+	router.HandleFunc("/api/jobs/summary", manager.HandleGetJobSummary).Methods("GET")
 	router.HandleFunc("/api/jobs/{id}", manager.HandleGetJob).Methods("GET")
-	router.HandleFunc("/api/jobs/{id}", manager.HandleUpdateJob).Methods("PUT")
-	router.HandleFunc("/api/jobs/{id}", manager.HandleDeleteJob).Methods("DELETE")
+	router.HandleFunc("/api/jobs/{id}", writable(manager.HandleUpdateJob)).Methods("PUT")
+	router.HandleFunc("/api/jobs/{id}", writable(manager.HandlePatchJob)).Methods("PATCH")
+	router.HandleFunc("/api/jobs/{id}", writable(manager.HandleDeleteJob)).Methods("DELETE")
+	router.HandleFunc("/api/jobs/{id}/history", manager.HandleGetJobHistory).Methods("GET")
+	router.HandleFunc("/api/jobs/{id}/history", writable(manager.HandlePruneJobHistory)).Methods("DELETE")
+	router.HandleFunc("/api/jobs/{id}/logs", manager.HandleGetJobLogs).Methods("GET")
+	router.HandleFunc("/api/jobs/{id}/debug", manager.HandleGetJobDebug).Methods("GET")
+	router.HandleFunc("/api/jobs/{id}/clone", writable(manager.HandleCloneJob)).Methods("POST")
+	router.HandleFunc("/api/jobs/{id}/toggle", writable(manager.HandleToggleJob)).Methods("POST")
+	router.HandleFunc("/api/jobs/{id}/restore", writable(manager.HandleRestoreJob)).Methods("POST")
+	router.HandleFunc("/api/jobs/{id}/dry-run", manager.HandleDryRunJob).Methods("POST")
+	if disabled["secrets"] {
+		router.Handle("/api/jobs/{id}/secrets", featureDisabled("secrets"))
+	} else {
+		router.HandleFunc("/api/jobs/{id}/secrets", manager.HandleRevealJobSecrets).Methods("GET")
+	}
+	router.HandleFunc("/api/tags", manager.HandleGetTags).Methods("GET")
 
-	// Only register file endpoints if blob storage is available
-	if blobServer != nil {
+	// File endpoints need blob storage
+	switch {
+	case disabled["files"]:
+		router.PathPrefix("/api/files").Handler(featureDisabled("files"))
+	case blobServer != nil:
+		router.HandleFunc("/api/files/{name:.+}/url", blobServer.HandleSignedURL).Methods("GET")
+		router.HandleFunc("/api/files/{name:.+}/copy", writable(manager.RequireAdmin(blobServer.HandleCopyFile))).Methods("POST")
 		router.HandleFunc("/api/files", blobServer.HandleFiles).Methods("GET", "POST")
-		router.HandleFunc("/api/files/", blobServer.HandleFileOps).Methods("PUT", "DELETE")
+		router.PathPrefix("/api/files/").HandlerFunc(blobServer.HandleFileOps).Methods("PUT", "DELETE")
+	default:
+		router.PathPrefix("/api/files").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apierror.Write(w, "File storage not available. Configure Azure blob storage to enable this feature.", http.StatusServiceUnavailable)
+		})
+	}
+
+	if disabled["describe-cron"] {
+		router.PathPrefix("/api/describe-cron").Handler(featureDisabled("describe-cron"))
 	} else {
-		// Return 503 Service Unavailable for file endpoints when storage is not configured
-		router.HandleFunc("/api/files", func(w http.ResponseWriter, r *http.Request) {
-			http.Error(w, "File storage not available. Configure Azure blob storage to enable this feature.", http.StatusServiceUnavailable)
-		}).Methods("GET", "POST")
-		router.HandleFunc("/api/files/", func(w http.ResponseWriter, r *http.Request) {
-			http.Error(w, "File storage not available. Configure Azure blob storage to enable this feature.", http.StatusServiceUnavailable)
-		}).Methods("PUT", "DELETE")
+		router.HandleFunc("/api/describe-cron", manager.HandleDescribeCron).Methods("POST")
+		router.HandleFunc("/api/describe-cron/batch", manager.HandleDescribeCronBatch).Methods("POST")
 	}
+	if disabled["admin"] {
+		router.PathPrefix("/api/admin/").Handler(featureDisabled("admin"))
+	} else {
+		router.HandleFunc("/api/admin/reconcile", writable(manager.HandleReconcile)).Methods("POST")
+		router.HandleFunc("/api/admin/pause-all", writable(manager.HandlePauseAll)).Methods("POST")
+		router.HandleFunc("/api/admin/resume-all", writable(manager.HandleResumeAll)).Methods("POST")
+		router.HandleFunc("/api/admin/backup", writable(manager.HandleBackupNow)).Methods("POST")
+		router.HandleFunc("/api/admin/backups", manager.HandleListBackups).Methods("GET")
+		router.HandleFunc("/api/admin/backups/status", manager.HandleBackupStatus).Methods("GET")
+		router.HandleFunc("/api/admin/promote", manager.HandlePromote).Methods("POST")
+	}
+	router.HandleFunc("/api/schedule-presets", manager.HandleGetSchedulePresets).Methods("GET")
+	router.HandleFunc("/api/job-types", manager.HandleGetJobTypes).Methods("GET")
 
-	router.HandleFunc("/api/describe-cron", manager.HandleDescribeCron).Methods("POST")
+	router.HandleFunc("/api/version", handleVersion).Methods("GET")
+	if disabled["metrics"] {
+		router.Handle("/metrics", featureDisabled("metrics"))
+	} else {
+		router.HandleFunc("/metrics", manager.HandleMetrics).Methods("GET")
+	}
 
 	// Heartbeat endpoint
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Status      string                `json:"status"`
+			Maintenance bool                  `json:"maintenance"`
+			ReadOnly    bool                  `json:"readOnly"`
+			Backup      *cronmgr.BackupHealth `json:"backup,omitempty"`
+		}{"ok", manager.Paused(), manager.ReadOnly(), manager.BackupHealth()})
 	}).Methods("GET")
 
-	handler := cronmgr.EnableCORS(router)
+	corsOrigins := splitList(os.Getenv("CORS_ALLOWED_ORIGINS"))
+	if len(corsOrigins) > 0 {
+		slog.Info("CORS enabled", "allowed_origins", corsOrigins)
+	}
+	corsMaxAge, err := envDuration("CORS_MAX_AGE", cronmgr.DefaultCORSMaxAge)
+	if err != nil {
+		slog.Error("Invalid CORS max age", "error", err)
+		os.Exit(1)
+	}
+	handler := cronmgr.EnableCORS(cronmgr.CORSConfig{
+		AllowedOrigins: corsOrigins,
+		AllowedMethods: splitList(os.Getenv("CORS_ALLOWED_METHODS")),
+		AllowedHeaders: splitList(os.Getenv("CORS_ALLOWED_HEADERS")),
+		MaxAge:         corsMaxAge,
+	}, router)
 	handler = securityHeadersMiddleware(handler)
+	handler = requestid.Middleware(handler)
 
-	slog.Info("Server starting", "address", ":8080")
-	if err := http.ListenAndServe(":8080", handler); err != nil {
-		slog.Error("Server failed to start", "error", err)
+	readHeaderTimeout, err := envDuration("HTTP_READ_HEADER_TIMEOUT", 10*time.Second)
+	if err != nil {
+		slog.Error("Invalid HTTP read header timeout", "error", err)
 		os.Exit(1)
 	}
+	readTimeout, err := envDuration("HTTP_READ_TIMEOUT", 30*time.Second)
+	if err != nil {
+		slog.Error("Invalid HTTP read timeout", "error", err)
+		os.Exit(1)
+	}
+	writeTimeout, err := envDuration("HTTP_WRITE_TIMEOUT", 60*time.Second)
+	if err != nil {
+		slog.Error("Invalid HTTP write timeout", "error", err)
+		os.Exit(1)
+	}
+	idleTimeout, err := envDuration("HTTP_IDLE_TIMEOUT", 120*time.Second)
+	if err != nil {
+		slog.Error("Invalid HTTP idle timeout", "error", err)
+		os.Exit(1)
+	}
+	srv := &http.Server{
+		Addr:              ":8080",
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+	srv.RegisterOnShutdown(manager.StopLogFollowers)
+	go func() {
+		slog.Info("Server starting", "address", srv.Addr)
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Server failed to start", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// On SIGINT/SIGTERM stop accepting requests, then return so the deferred
+	// manager.Stop() saves jobs within the shutdown timeout
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-sigCtx.Done()
+	slog.Info("Shutting down", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("HTTP server did not shut down cleanly", "error", err)
+	}
+}
+
+// envOr returns the value of the env var key, or def if it is unset or empty.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envDuration parses the env var key as a positive duration, returning def if
+// it is unset.
+func envDuration(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %s", key, v)
+	}
+	return d, nil
+}
+
+// envInt parses the env var key as a non-negative integer, returning def if
+// it is unset.
+func envInt(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%s must not be negative, got %d", key, n)
+	}
+	return n, nil
+}
+
+// splitList parses a comma-separated env value, dropping empty entries.
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// features are the route groups DISABLED_FEATURES can turn off
+var features = []string{"files", "describe-cron", "admin", "metrics", "secrets"}
+
+// disabledFeatures parses a comma-separated DISABLED_FEATURES value,
+// rejecting names that aren't in features so typos don't leave a route on.
+func disabledFeatures(v string) (map[string]bool, error) {
+	disabled := make(map[string]bool)
+	for _, name := range splitList(v) {
+		name = strings.ToLower(name)
+		if !slices.Contains(features, name) {
+			return nil, fmt.Errorf("unknown feature %q, expected one of %s", name, strings.Join(features, ", "))
+		}
+		disabled[name] = true
+	}
+	return disabled, nil
+}
+
+// featureDisabled answers requests to a disabled feature's routes with 404,
+// as if they didn't exist
+func featureDisabled(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, fmt.Sprintf("%s is disabled on this server", name), http.StatusNotFound)
+	})
+}
+
+// handleVersion reports the running build and how long it has been up
+func handleVersion(w http.ResponseWriter, r *http.Request) {
+	rev := commit
+	if rev == "" {
+		// Fall back to the VCS stamp the go tool embeds in local builds
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range info.Settings {
+				if setting.Key == "vcs.revision" {
+					rev = setting.Value
+				}
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"version":   version,
+		"commit":    rev,
+		"buildDate": buildDate,
+		"goVersion": runtime.Version(),
+		"startedAt": startTime.UTC(),
+		"uptime":    time.Since(startTime).Round(time.Second).String(),
+	})
 }
 
 func securityHeadersMiddleware(next http.Handler) http.Handler {
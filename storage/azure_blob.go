@@ -2,27 +2,42 @@ package storage
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 )
 
 type AzureBlobStorage struct {
 	containerClient *container.Client
 	cdnBaseURL      string
+	retry           RetryOptions
 }
 
-func NewAzureBlobStorage(accountName, accountKey, containerName, cdnBaseURL string) (*AzureBlobStorage, error) {
+// NewAzureBlobStorage connects to a container. Transient failures are
+// retried as retry says, in place of the Azure SDK's own retries, so that
+// one setting controls how hard each call tries.
+func NewAzureBlobStorage(accountName, accountKey, containerName, cdnBaseURL string, retry RetryOptions) (*AzureBlobStorage, error) {
 	cred, err := azblob.NewSharedKeyCredential(accountName, accountKey)
 	if err != nil {
 		return nil, err
 	}
 
 	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", accountName)
-	client, err := azblob.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
+	opts := &azblob.ClientOptions{}
+	opts.Retry = policy.RetryOptions{MaxRetries: -1}
+	client, err := azblob.NewClientWithSharedKeyCredential(serviceURL, cred, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -31,63 +46,270 @@ func NewAzureBlobStorage(accountName, accountKey, containerName, cdnBaseURL stri
 	return &AzureBlobStorage{
 		containerClient: containerClient,
 		cdnBaseURL:      cdnBaseURL,
+		retry:           retry,
 	}, nil
 }
 
 func (s *AzureBlobStorage) DownloadFile(ctx context.Context, name string) (io.ReadCloser, error) {
-	blob := s.containerClient.NewBlockBlobClient(name)
-	resp, err := blob.DownloadStream(ctx, nil)
+	blobClient := s.containerClient.NewBlockBlobClient(name)
+	var resp blob.DownloadStreamResponse
+	err := s.retry.do(ctx, "download", name, func(int) error {
+		var err error
+		resp, err = blobClient.DownloadStream(ctx, nil)
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return nil, notFound(name, err)
 	}
-	return resp.Body, nil
+	// Reads that fail part way resume from where they stopped
+	return resp.NewRetryReader(ctx, &blob.RetryReaderOptions{MaxRetries: int32(s.retry.Retries)}), nil
 }
 
-func (s *AzureBlobStorage) ListFiles(ctx context.Context) ([]FileInfo, error) {
-	pager := s.containerClient.NewListBlobsFlatPager(nil)
+func (s *AzureBlobStorage) ListFiles(ctx context.Context, prefix string) ([]FileInfo, error) {
+	opts := &container.ListBlobsFlatOptions{}
+	if prefix != "" {
+		opts.Prefix = &prefix
+	}
+	pager := s.containerClient.NewListBlobsFlatPager(opts)
 	var files []FileInfo
 	for pager.More() {
-		page, err := pager.NextPage(ctx)
+		var page container.ListBlobsFlatResponse
+		err := s.retry.do(ctx, "list", prefix, func(int) error {
+			var err error
+			page, err = pager.NextPage(ctx)
+			return err
+		})
 		if err != nil {
 			return nil, err
 		}
-		for _, blob := range page.Segment.BlobItems {
-			files = append(files, FileInfo{
-				Name: *blob.Name,
-				URL:  fmt.Sprintf("%s/%s", s.cdnBaseURL, *blob.Name),
-			})
+		for _, item := range page.Segment.BlobItems {
+			files = append(files, s.blobInfo(item))
 		}
 	}
 	return files, nil
 }
 
+// blobInfo describes a blob from a listing
+func (s *AzureBlobStorage) blobInfo(item *container.BlobItem) FileInfo {
+	info := FileInfo{
+		Name: *item.Name,
+		URL:  fmt.Sprintf("%s/%s", s.cdnBaseURL, *item.Name),
+	}
+	if props := item.Properties; props != nil {
+		info.ETag = etagString(props.ETag)
+		if len(props.ContentMD5) > 0 {
+			info.ContentMD5 = hex.EncodeToString(props.ContentMD5)
+		}
+		info.LastModified = props.LastModified
+		if props.ContentLength != nil {
+			info.Size = *props.ContentLength
+		}
+	}
+	return info
+}
+
+// maxPageSize is the most blobs Azure returns in a single listing call
+const maxPageSize = 5000
+
+func (s *AzureBlobStorage) ListFilesPage(ctx context.Context, prefix, continuation string, limit int) (FilePage, error) {
+	if limit <= 0 || limit > maxPageSize {
+		limit = maxPageSize
+	}
+	maxResults := int32(limit)
+	opts := &container.ListBlobsFlatOptions{MaxResults: &maxResults}
+	if prefix != "" {
+		opts.Prefix = &prefix
+	}
+	if continuation != "" {
+		opts.Marker = &continuation
+	}
+
+	pager := s.containerClient.NewListBlobsFlatPager(opts)
+	var page container.ListBlobsFlatResponse
+	err := s.retry.do(ctx, "list", prefix, func(int) error {
+		var err error
+		page, err = pager.NextPage(ctx)
+		return err
+	})
+	if err != nil {
+		return FilePage{}, err
+	}
+	result := FilePage{Files: make([]FileInfo, 0, len(page.Segment.BlobItems))}
+	for _, item := range page.Segment.BlobItems {
+		result.Files = append(result.Files, s.blobInfo(item))
+	}
+	if page.NextMarker != nil {
+		result.Continuation = *page.NextMarker
+	}
+	return result, nil
+}
+
 func (s *AzureBlobStorage) UploadFile(ctx context.Context, name string, data io.Reader) (FileInfo, error) {
+	return s.upload(ctx, name, data, nil)
+}
+
+func (s *AzureBlobStorage) UploadFileIfMatch(ctx context.Context, name string, data io.Reader, ifMatch string) (FileInfo, error) {
+	return s.upload(ctx, name, data, ifMatchConditions(ifMatch))
+}
+
+func (s *AzureBlobStorage) CreateFile(ctx context.Context, name string, data io.Reader) (FileInfo, error) {
+	etag := azcore.ETagAny
+	conds := &blob.AccessConditions{ModifiedAccessConditions: &blob.ModifiedAccessConditions{IfNoneMatch: &etag}}
+	info, err := s.upload(ctx, name, data, conds)
+	if errors.Is(err, ErrPreconditionFailed) || bloberror.HasCode(err, bloberror.BlobAlreadyExists) {
+		return FileInfo{}, fmt.Errorf("%w: %s", ErrAlreadyExists, name)
+	}
+	return info, err
+}
+
+// upload writes data to the named blob, reporting the MD5 of what was sent.
+// Azure checks each block against a CRC64 as it arrives, so that is what it
+// stored, without reading the blob back.
+//
+// An unconditional upload that fails transiently is retried, from the start
+// of data if it is an io.Seeker or else only if none of it was read.
+// Conditional uploads are tried once: if a failed attempt had in fact been
+// stored, a retry would fail its condition.
+func (s *AzureBlobStorage) upload(ctx context.Context, name string, data io.Reader, conds *blob.AccessConditions) (FileInfo, error) {
 	blobClient := s.containerClient.NewBlockBlobClient(name)
-	_, err := blobClient.UploadStream(ctx, data, &blockblob.UploadStreamOptions{})
+	opts := &blockblob.UploadStreamOptions{
+		AccessConditions:        conds,
+		TransactionalValidation: blob.TransferValidationTypeComputeCRC64(),
+	}
+
+	retry := s.retry
+	if conds != nil {
+		retry.Retries = 0
+	}
+	seeker, _ := data.(io.Seeker)
+	var start int64
+	if seeker != nil {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seeker = nil
+		}
+	}
+	body := &countingReader{r: data}
+
+	var resp blockblob.UploadStreamResponse
+	hash := md5.New()
+	err := retry.do(ctx, "upload", name, func(attempt int) error {
+		if attempt > 0 && body.n > 0 {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return permanentError{err}
+			}
+			body.n = 0
+		}
+		hash.Reset()
+		var err error
+		resp, err = blobClient.UploadStream(ctx, io.TeeReader(body, hash), opts)
+		if err != nil && body.n > 0 && seeker == nil {
+			return permanentError{err}
+		}
+		return err
+	})
 	if err != nil {
-		return FileInfo{}, err
+		return FileInfo{}, conditionFailed(name, err)
 	}
 	return FileInfo{
-		Name: name,
-		URL:  fmt.Sprintf("%s/%s", s.cdnBaseURL, name),
+		Name:       name,
+		URL:        fmt.Sprintf("%s/%s", s.cdnBaseURL, name),
+		ETag:       etagString(resp.ETag),
+		ContentMD5: hex.EncodeToString(hash.Sum(nil)),
 	}, nil
 }
 
 func (s *AzureBlobStorage) DeleteFile(ctx context.Context, name string) error {
+	return notFound(name, s.deleteBlob(ctx, name, nil))
+}
+
+func (s *AzureBlobStorage) DeleteFileIfMatch(ctx context.Context, name, ifMatch string) error {
+	return conditionFailed(name, s.deleteBlob(ctx, name, ifMatchConditions(ifMatch)))
+}
+
+// deleteBlob deletes the named blob, retrying transient failures. A retry
+// that finds the blob gone counts as success, since the attempt that failed
+// most likely deleted it.
+func (s *AzureBlobStorage) deleteBlob(ctx context.Context, name string, conds *blob.AccessConditions) error {
 	blobClient := s.containerClient.NewBlobClient(name)
-	_, err := blobClient.Delete(ctx, nil)
-	return err
+	return s.retry.do(ctx, "delete", name, func(attempt int) error {
+		_, err := blobClient.Delete(ctx, &blob.DeleteOptions{AccessConditions: conds})
+		if attempt > 0 && bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil
+		}
+		return err
+	})
 }
 
+// RenameFile copies the blob to its new name and then deletes the original.
+// Both steps are pinned to the original's ETag, so a concurrent write to it
+// makes the rename fail with ErrPreconditionFailed instead of losing data.
 func (s *AzureBlobStorage) RenameFile(ctx context.Context, oldName, newName string) error {
 	oldBlob := s.containerClient.NewBlobClient(oldName)
 	newBlob := s.containerClient.NewBlockBlobClient(newName)
 
-	_, err := newBlob.StartCopyFromURL(ctx, oldBlob.URL(), nil)
+	var props blob.GetPropertiesResponse
+	err := s.retry.do(ctx, "rename", oldName, func(int) error {
+		var err error
+		props, err = oldBlob.GetProperties(ctx, nil)
+		return err
+	})
 	if err != nil {
+		return notFound(oldName, err)
+	}
+
+	err = s.retry.do(ctx, "rename", oldName, func(int) error {
+		_, err := newBlob.StartCopyFromURL(ctx, oldBlob.URL(), &blob.StartCopyFromURLOptions{
+			SourceModifiedAccessConditions: &blob.SourceModifiedAccessConditions{SourceIfMatch: props.ETag},
+		})
 		return err
+	})
+	if err != nil {
+		return conditionFailed(oldName, err)
+	}
+
+	err = s.deleteBlob(ctx, oldName, &blob.AccessConditions{
+		ModifiedAccessConditions: &blob.ModifiedAccessConditions{IfMatch: props.ETag},
+	})
+	return conditionFailed(oldName, err)
+}
+
+// ifMatchConditions builds access conditions requiring the given ETag
+func ifMatchConditions(ifMatch string) *blob.AccessConditions {
+	etag := azcore.ETag(ifMatch)
+	return &blob.AccessConditions{ModifiedAccessConditions: &blob.ModifiedAccessConditions{IfMatch: &etag}}
+}
+
+func etagString(etag *azcore.ETag) string {
+	if etag == nil {
+		return ""
+	}
+	return string(*etag)
+}
+
+// conditionFailed translates Azure's failed-precondition errors into
+// ErrPreconditionFailed, and missing blobs into ErrNotFound.
+func conditionFailed(name string, err error) error {
+	if bloberror.HasCode(err, bloberror.ConditionNotMet, bloberror.SourceConditionNotMet) {
+		return fmt.Errorf("%w: %s", ErrPreconditionFailed, name)
 	}
+	return notFound(name, err)
+}
 
-	_, err = oldBlob.Delete(ctx, nil)
+// notFound translates Azure's missing-blob errors into ErrNotFound
+func notFound(name string, err error) error {
+	if bloberror.HasCode(err, bloberror.BlobNotFound, bloberror.CannotVerifyCopySource) {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
 	return err
 }
+
+func (s *AzureBlobStorage) SignedURL(ctx context.Context, name string, ttl time.Duration) (string, error) {
+	blobClient := s.containerClient.NewBlobClient(name)
+	if _, err := blobClient.GetProperties(ctx, nil); err != nil {
+		return "", notFound(name, err)
+	}
+	// Start slightly in the past to tolerate clock skew with Azure
+	start := time.Now().Add(-5 * time.Minute)
+	return blobClient.GetSASURL(sas.BlobPermissions{Read: true}, time.Now().Add(ttl), &blob.GetSASURLOptions{StartTime: &start})
+}
@@ -0,0 +1,56 @@
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+
+	"tapasrm.dev/cron-ui/storage"
+	"tapasrm.dev/cron-ui/storage/storagetest"
+)
+
+// TestAzureBlobStorage runs the conformance suite against a real storage
+// account, in a container created for each subtest and deleted after it.
+// It is skipped unless AZURE_TEST_STORAGE_ACCOUNT and
+// AZURE_TEST_STORAGE_KEY are set.
+func TestAzureBlobStorage(t *testing.T) {
+	account := os.Getenv("AZURE_TEST_STORAGE_ACCOUNT")
+	key := os.Getenv("AZURE_TEST_STORAGE_KEY")
+	if account == "" || key == "" {
+		t.Skip("AZURE_TEST_STORAGE_ACCOUNT and AZURE_TEST_STORAGE_KEY are not set")
+	}
+
+	cred, err := azblob.NewSharedKeyCredential(account, key)
+	if err != nil {
+		t.Fatalf("NewSharedKeyCredential: %v", err)
+	}
+	client, err := azblob.NewClientWithSharedKeyCredential(fmt.Sprintf("https://%s.blob.core.windows.net/", account), cred, nil)
+	if err != nil {
+		t.Fatalf("NewClientWithSharedKeyCredential: %v", err)
+	}
+
+	n := 0
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		n++
+		name := fmt.Sprintf("storagetest-%d-%d", time.Now().UnixNano(), n)
+		ctx := context.Background()
+		if _, err := client.CreateContainer(ctx, name, nil); err != nil {
+			t.Fatalf("CreateContainer(%s): %v", name, err)
+		}
+		t.Cleanup(func() {
+			if _, err := client.DeleteContainer(context.Background(), name, nil); err != nil {
+				t.Logf("DeleteContainer(%s): %v", name, err)
+			}
+		})
+
+		s, err := storage.NewAzureBlobStorage(account, key, name, "", storage.DefaultRetryOptions)
+		if err != nil {
+			t.Fatalf("NewAzureBlobStorage: %v", err)
+		}
+		return s
+	})
+}
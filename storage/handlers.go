@@ -3,64 +3,192 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/gorilla/mux"
+	"tapasrm.dev/cron-ui/apierror"
 )
 
+// DefaultOpTimeout bounds a storage operation whose BlobServer timeout is
+// not set
+const DefaultOpTimeout = 30 * time.Second
+
 // BlobServer manages different types of storage.
 type BlobServer struct {
 	Assets  Storage
 	Backups Storage
+	// UploadTimeout, if set, replaces the server's read and write timeouts
+	// and the usual 30s storage timeout for uploads, which can take longer
+	UploadTimeout time.Duration
+	// UploadMinRate, in bytes per second, extends an upload's timeout by the
+	// time its declared Content-Length takes at that rate, so large files
+	// get longer. Zero disables the extension.
+	UploadMinRate int64
+	// ListTimeout, DownloadTimeout and DeleteTimeout bound listing files,
+	// creating download URLs, and deleting or renaming files. Zero means
+	// DefaultOpTimeout.
+	ListTimeout     time.Duration
+	DownloadTimeout time.Duration
+	DeleteTimeout   time.Duration
+	// ProtectedNames can't be copied to or from, such as the database backup
+	// and its checksum, which would otherwise leak the database or let it be
+	// replaced
+	ProtectedNames []string
+}
+
+// opTimeout returns d, or DefaultOpTimeout if it is unset
+func opTimeout(d time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return DefaultOpTimeout
+}
+
+// uploadTimeout returns how long the upload in r may take: UploadTimeout,
+// plus the time its body takes at UploadMinRate
+func (s *BlobServer) uploadTimeout(r *http.Request) time.Duration {
+	timeout := opTimeout(s.UploadTimeout)
+	if s.UploadMinRate > 0 && r.ContentLength > 0 {
+		timeout += time.Duration(r.ContentLength/s.UploadMinRate) * time.Second
+	}
+	return timeout
 }
 
 func (s *BlobServer) HandleFiles(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	timeout := opTimeout(s.ListTimeout)
+	if r.Method == http.MethodPost {
+		timeout = s.uploadTimeout(r)
+	}
+	if r.Method == http.MethodPost && (s.UploadTimeout > 0 || s.UploadMinRate > 0) {
+		deadline := time.Now().Add(timeout)
+		rc := http.NewResponseController(w)
+		if err := rc.SetReadDeadline(deadline); err != nil {
+			slog.WarnContext(r.Context(), "Could not extend upload read deadline", "error", err)
+		}
+		if err := rc.SetWriteDeadline(deadline); err != nil {
+			slog.WarnContext(r.Context(), "Could not extend upload write deadline", "error", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
 	defer cancel()
 
 	switch r.Method {
 	case http.MethodGet:
-		files, err := s.Assets.ListFiles(ctx)
+		// ?prefix= lists only names under that prefix, e.g. "images/".
+		// ?limit= or ?continuation= switch to a paged listing; without them
+		// every match is returned as a plain array, in storage order unless
+		// ?sort=name|size|modified and optionally ?order=asc|desc are given.
+		q := r.URL.Query()
+		prefix := q.Get("prefix")
+		if q.Has("limit") || q.Has("continuation") {
+			if q.Has("sort") {
+				apierror.Write(w, "sort cannot be combined with a paged listing", http.StatusBadRequest)
+				return
+			}
+			limit := 0
+			if v := q.Get("limit"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n <= 0 {
+					apierror.Write(w, "limit must be a positive integer", http.StatusBadRequest)
+					return
+				}
+				limit = n
+			}
+			page, err := s.Assets.ListFilesPage(ctx, prefix, q.Get("continuation"), limit)
+			if err != nil {
+				apierror.Write(w, err.Error(), errorStatus(err))
+				return
+			}
+			json.NewEncoder(w).Encode(page)
+			return
+		}
+
+		files, err := s.Assets.ListFiles(ctx, prefix)
 		if err != nil {
-			http.Error(w, err.Error(), 500)
+			apierror.Write(w, err.Error(), errorStatus(err))
 			return
 		}
+		if by := q.Get("sort"); by != "" {
+			if err := SortFiles(files, by, q.Get("order")); err != nil {
+				apierror.Write(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
 		json.NewEncoder(w).Encode(files)
 
 	case http.MethodPost:
 		file, header, err := r.FormFile("file")
 		if err != nil {
-			http.Error(w, "file not found", 400)
+			apierror.Write(w, "multipart field \"file\" is required", http.StatusBadRequest)
 			return
 		}
 		defer file.Close()
 
-		info, err := s.Assets.UploadFile(ctx, header.Filename, file)
+		// ?folder= (or a "folder" form field) namespaces the upload
+		folder := r.URL.Query().Get("folder")
+		if folder == "" {
+			folder = r.FormValue("folder")
+		}
+		folder, err = CleanFolder(folder)
+		if err != nil {
+			apierror.Write(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fileName, err := SanitizeFileName(header.Filename)
+		if err != nil {
+			apierror.Write(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		name := joinFolder(folder, fileName)
+
+		var info FileInfo
+		if ifMatch := requestIfMatch(r); ifMatch != "" {
+			info, err = s.Assets.UploadFileIfMatch(ctx, name, file, ifMatch)
+		} else {
+			info, err = s.Assets.UploadFile(ctx, name, file)
+		}
 		if err != nil {
-			http.Error(w, err.Error(), 500)
+			apierror.Write(w, err.Error(), errorStatus(err))
 			return
 		}
 		json.NewEncoder(w).Encode(info)
 
 	default:
-		http.Error(w, "method not allowed", 405)
+		apierror.Write(w, "method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
 func (s *BlobServer) HandleFileOps(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), opTimeout(s.DeleteTimeout))
 	defer cancel()
 
-	name := strings.TrimPrefix(r.URL.Path, "/files/")
+	name := strings.TrimPrefix(r.URL.Path, "/api/files/")
 	if name == "" {
-		http.Error(w, "filename required", 400)
+		apierror.Write(w, "filename required", http.StatusBadRequest)
 		return
 	}
 
 	switch {
 	case r.Method == http.MethodDelete:
-		if err := s.Assets.DeleteFile(ctx, name); err != nil {
-			http.Error(w, err.Error(), 500)
+		if err := checkBlobName(name); err != nil {
+			apierror.Write(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var err error
+		if ifMatch := requestIfMatch(r); ifMatch != "" {
+			err = s.Assets.DeleteFileIfMatch(ctx, name, ifMatch)
+		} else {
+			err = s.Assets.DeleteFile(ctx, name)
+		}
+		if err != nil {
+			apierror.Write(w, err.Error(), errorStatus(err))
 			return
 		}
 		w.Write([]byte("Deleted\n"))
@@ -69,16 +197,181 @@ func (s *BlobServer) HandleFileOps(w http.ResponseWriter, r *http.Request) {
 		oldName := strings.TrimSuffix(name, "/rename")
 		newName := r.URL.Query().Get("to")
 		if newName == "" {
-			http.Error(w, "missing ?to=<newName>", 400)
+			apierror.Write(w, "missing ?to=<newName>", http.StatusBadRequest)
+			return
+		}
+		if err := checkBlobName(oldName); err != nil {
+			apierror.Write(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		newName, err := SanitizeBlobName(newName)
+		if err != nil {
+			apierror.Write(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 		if err := s.Assets.RenameFile(ctx, oldName, newName); err != nil {
-			http.Error(w, err.Error(), 500)
+			apierror.Write(w, err.Error(), errorStatus(err))
 			return
 		}
 		w.Write([]byte("Renamed\n"))
 
 	default:
-		http.Error(w, "unsupported operation", 405)
+		apierror.Write(w, "unsupported operation", http.StatusMethodNotAllowed)
+	}
+}
+
+// errorStatus maps a storage error to an HTTP status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// requestIfMatch returns the ETag a write is conditional on, from the
+// ?ifMatch= parameter or the If-Match header.
+func requestIfMatch(r *http.Request) string {
+	if v := r.URL.Query().Get("ifMatch"); v != "" {
+		return v
+	}
+	return r.Header.Get("If-Match")
+}
+
+// Bounds for the lifetime of signed download URLs
+const (
+	defaultSignedURLTTL = 15 * time.Minute
+	maxSignedURLTTL     = 24 * time.Hour
+)
+
+// HandleSignedURL returns a temporary download link for a file. ?ttl= sets
+// how long it stays valid as a Go duration (default 15m, at most 24h).
+func (s *BlobServer) HandleSignedURL(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), opTimeout(s.DownloadTimeout))
+	defer cancel()
+
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		apierror.Write(w, "filename required", http.StatusBadRequest)
+		return
+	}
+
+	ttl := defaultSignedURLTTL
+	if v := r.URL.Query().Get("ttl"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > maxSignedURLTTL {
+			apierror.Write(w, "ttl must be a positive duration no longer than 24h", http.StatusBadRequest)
+			return
+		}
+		ttl = d
+	}
+
+	url, err := s.Assets.SignedURL(ctx, name, ttl)
+	if err != nil {
+		apierror.Write(w, err.Error(), errorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"name":      name,
+		"url":       url,
+		"expiresAt": time.Now().Add(ttl).UTC(),
+	})
+}
+
+// store returns the store named by a ?to= or ?from= parameter
+func (s *BlobServer) store(name string) (Storage, bool) {
+	switch name {
+	case "assets":
+		return s.Assets, true
+	case "backups":
+		return s.Backups, true
+	default:
+		return nil, false
+	}
+}
+
+// HandleCopyFile copies a file between the asset and backup stores.
+// ?to=assets|backups picks the destination, and the file is read from the
+// other store unless ?from= says otherwise. ?name= gives the copy a
+// different name, ?move=true deletes the source once the copy is stored, and
+// If-Match makes the copy conditional on the destination's current ETag.
+// ProtectedNames are refused. Callers must check the request is authorized.
+func (s *BlobServer) HandleCopyFile(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), opTimeout(s.UploadTimeout))
+	defer cancel()
+
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		apierror.Write(w, "filename required", http.StatusBadRequest)
+		return
+	}
+	if err := checkBlobName(name); err != nil {
+		apierror.Write(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	to := q.Get("to")
+	dst, ok := s.store(to)
+	if !ok {
+		apierror.Write(w, "to must be assets or backups", http.StatusBadRequest)
+		return
+	}
+	from := q.Get("from")
+	if from == "" {
+		from = "assets"
+		if to == "assets" {
+			from = "backups"
+		}
+	}
+	src, ok := s.store(from)
+	if !ok {
+		apierror.Write(w, "from must be assets or backups", http.StatusBadRequest)
+		return
+	}
+
+	dstName := name
+	if v := q.Get("name"); v != "" {
+		clean, err := SanitizeBlobName(v)
+		if err != nil {
+			apierror.Write(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		dstName = clean
+	}
+	if slices.Contains(s.ProtectedNames, name) || slices.Contains(s.ProtectedNames, dstName) {
+		apierror.Write(w, "the database backup can't be copied or overwritten", http.StatusForbidden)
+		return
+	}
+	if from == to && dstName == name {
+		apierror.Write(w, "source and destination are the same file", http.StatusBadRequest)
+		return
+	}
+
+	move := false
+	if v := q.Get("move"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			apierror.Write(w, "move must be true or false", http.StatusBadRequest)
+			return
+		}
+		move = b
+	}
+
+	info, err := CopyFile(ctx, src, dst, name, dstName, requestIfMatch(r))
+	if err != nil {
+		apierror.Write(w, err.Error(), errorStatus(err))
+		return
+	}
+	if move {
+		if err := src.DeleteFile(ctx, name); err != nil && !errors.Is(err, ErrNotFound) {
+			apierror.Write(w, fmt.Sprintf("copied to %s as %q but could not delete the source: %v", to, info.Name, err), errorStatus(err))
+			return
+		}
 	}
+	json.NewEncoder(w).Encode(info)
 }
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStorage is a Storage backed by a map, for tests and local runs
+// without Azure. The zero value is ready to use.
+type MemoryStorage struct {
+	// BaseURL prefixes the URLs reported for files
+	BaseURL string
+
+	mu      sync.RWMutex
+	files   map[string]memoryFile
+	version uint64
+}
+
+type memoryFile struct {
+	data     []byte
+	etag     string
+	md5      string
+	modified time.Time
+}
+
+// NewMemoryStorage returns an empty MemoryStorage reporting URLs under baseURL
+func NewMemoryStorage(baseURL string) *MemoryStorage {
+	return &MemoryStorage{BaseURL: baseURL}
+}
+
+// info describes a stored file. Must be called with s.mu held.
+func (s *MemoryStorage) info(name string) FileInfo {
+	f := s.files[name]
+	return FileInfo{
+		Name:         name,
+		URL:          fmt.Sprintf("%s/%s", s.BaseURL, name),
+		ETag:         f.etag,
+		ContentMD5:   f.md5,
+		Size:         int64(len(f.data)),
+		LastModified: &f.modified,
+	}
+}
+
+// put stores data under name with a fresh ETag. Must be called with s.mu
+// held for writing.
+func (s *MemoryStorage) put(name string, data []byte) {
+	if s.files == nil {
+		s.files = make(map[string]memoryFile)
+	}
+	s.version++
+	sum := md5.Sum(data)
+	s.files[name] = memoryFile{
+		data:     data,
+		etag:     fmt.Sprintf("\"%d\"", s.version),
+		md5:      hex.EncodeToString(sum[:]),
+		modified: time.Now(),
+	}
+}
+
+// checkMatch returns ErrPreconditionFailed unless name exists with the given
+// ETag. Must be called with s.mu held.
+func (s *MemoryStorage) checkMatch(name, ifMatch string) error {
+	f, ok := s.files[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if ifMatch != "*" && f.etag != ifMatch {
+		return fmt.Errorf("%w: %s", ErrPreconditionFailed, name)
+	}
+	return nil
+}
+
+// sortedNames returns the names under prefix in lexical order. Must be
+// called with s.mu held.
+func (s *MemoryStorage) sortedNames(prefix string) []string {
+	names := make([]string, 0, len(s.files))
+	for name := range s.files {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (s *MemoryStorage) ListFiles(ctx context.Context, prefix string) ([]FileInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var files []FileInfo
+	for _, name := range s.sortedNames(prefix) {
+		files = append(files, s.info(name))
+	}
+	return files, nil
+}
+
+// ListFilesPage pages through names in lexical order. The continuation
+// token is the last name of the previous page.
+func (s *MemoryStorage) ListFilesPage(ctx context.Context, prefix, continuation string, limit int) (FilePage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := s.sortedNames(prefix)
+	if continuation != "" {
+		i, _ := slices.BinarySearch(names, continuation)
+		for i < len(names) && names[i] <= continuation {
+			i++
+		}
+		names = names[i:]
+	}
+	if limit <= 0 || limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	page := FilePage{Files: []FileInfo{}}
+	for _, name := range names {
+		if len(page.Files) == limit {
+			page.Continuation = page.Files[len(page.Files)-1].Name
+			break
+		}
+		page.Files = append(page.Files, s.info(name))
+	}
+	return page, nil
+}
+
+func (s *MemoryStorage) DownloadFile(ctx context.Context, name string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return io.NopCloser(bytes.NewReader(f.data)), nil
+}
+
+func (s *MemoryStorage) UploadFile(ctx context.Context, name string, data io.Reader) (FileInfo, error) {
+	buf, err := io.ReadAll(data)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(name, buf)
+	return s.info(name), nil
+}
+
+func (s *MemoryStorage) UploadFileIfMatch(ctx context.Context, name string, data io.Reader, ifMatch string) (FileInfo, error) {
+	buf, err := io.ReadAll(data)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkMatch(name, ifMatch); err != nil {
+		return FileInfo{}, err
+	}
+	s.put(name, buf)
+	return s.info(name), nil
+}
+
+func (s *MemoryStorage) CreateFile(ctx context.Context, name string, data io.Reader) (FileInfo, error) {
+	buf, err := io.ReadAll(data)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[name]; ok {
+		return FileInfo{}, fmt.Errorf("%w: %s", ErrAlreadyExists, name)
+	}
+	s.put(name, buf)
+	return s.info(name), nil
+}
+
+func (s *MemoryStorage) DeleteFile(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.files[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(s.files, name)
+	return nil
+}
+
+func (s *MemoryStorage) DeleteFileIfMatch(ctx context.Context, name, ifMatch string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkMatch(name, ifMatch); err != nil {
+		return err
+	}
+	delete(s.files, name)
+	return nil
+}
+
+func (s *MemoryStorage) RenameFile(ctx context.Context, oldName, newName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.files[oldName]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, oldName)
+	}
+	delete(s.files, oldName)
+	s.put(newName, f.data)
+	return nil
+}
+
+// SignedURL returns the file's URL with an expiry parameter. Nothing checks
+// it; it only mirrors the shape of a real signed URL.
+func (s *MemoryStorage) SignedURL(ctx context.Context, name string, ttl time.Duration) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.files[name]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return fmt.Sprintf("%s?expires=%d", s.info(name).URL, time.Now().Add(ttl).Unix()), nil
+}
@@ -0,0 +1,14 @@
+package storage_test
+
+import (
+	"testing"
+
+	"tapasrm.dev/cron-ui/storage"
+	"tapasrm.dev/cron-ui/storage/storagetest"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return storage.NewMemoryStorage("https://files.example.com")
+	})
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// maxFileNameLen is the longest file name accepted, in bytes
+const maxFileNameLen = 255
+
+// CleanFolder normalizes a client-supplied folder prefix for uploads.
+// Leading and trailing slashes are dropped and any ".." segment is rejected,
+// so the result always stays inside the container. "" means the root.
+func CleanFolder(folder string) (string, error) {
+	clean := strings.ReplaceAll(folder, "\\", "/")
+	for _, seg := range strings.Split(clean, "/") {
+		if seg == ".." {
+			return "", fmt.Errorf("folder %q must not contain '..'", folder)
+		}
+	}
+	return strings.Trim(path.Clean("/"+clean), "/"), nil
+}
+
+// joinFolder places name inside folder
+func joinFolder(folder, name string) string {
+	if folder == "" {
+		return name
+	}
+	return folder + "/" + name
+}
+
+// SanitizeFileName turns a client-supplied file name into a safe blob name
+// with no folder part. Any directories in the name are dropped, characters
+// other than letters, digits, '.', '-', '_' and spaces become '_', and
+// leading dots are removed so names can't be hidden or relative. It fails if
+// nothing usable is left, including when the name ends in a separator.
+func SanitizeFileName(name string) (string, error) {
+	slashed := strings.ReplaceAll(name, "\\", "/")
+	base := slashed[strings.LastIndex(slashed, "/")+1:]
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r):
+			return -1
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '.', r == '-', r == '_', r == ' ':
+			return r
+		default:
+			return '_'
+		}
+	}, base)
+	clean = strings.TrimSpace(strings.TrimLeft(clean, ". "))
+	if clean == "" {
+		return "", fmt.Errorf("file name %q is not valid", name)
+	}
+	if len(clean) > maxFileNameLen {
+		return "", fmt.Errorf("file name is longer than %d bytes", maxFileNameLen)
+	}
+	return clean, nil
+}
+
+// SanitizeBlobName cleans a full blob path such as a rename target: the
+// folder part goes through CleanFolder and the last segment through
+// SanitizeFileName.
+func SanitizeBlobName(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	dir, file := path.Split(name)
+	folder, err := CleanFolder(dir)
+	if err != nil {
+		return "", err
+	}
+	clean, err := SanitizeFileName(file)
+	if err != nil {
+		return "", err
+	}
+	return joinFolder(folder, clean), nil
+}
+
+// checkBlobName rejects names of existing blobs that would escape the
+// container, without rewriting them, so files stored before sanitization
+// can still be renamed and deleted.
+func checkBlobName(name string) error {
+	for _, seg := range strings.Split(strings.ReplaceAll(name, "\\", "/"), "/") {
+		if seg == ".." || seg == "." {
+			return fmt.Errorf("file name %q must not contain '.' or '..' segments", name)
+		}
+	}
+	if name == "" || strings.HasPrefix(name, "/") || strings.ContainsFunc(name, unicode.IsControl) {
+		return fmt.Errorf("file name %q is not valid", name)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCleanFolder(t *testing.T) {
+	tests := []struct {
+		folder  string
+		want    string
+		wantErr bool
+	}{
+		{folder: "", want: ""},
+		{folder: "/", want: ""},
+		{folder: "docs/", want: "docs"},
+		{folder: "/abs/path", want: "abs/path"},
+		{folder: "a//b", want: "a/b"},
+		{folder: "./a", want: "a"},
+		{folder: `a\b`, want: "a/b"},
+		{folder: "...", want: "..."},
+		// Encoded separators are not decoded, so they stay literal
+		{folder: "%2e%2e%2fetc", want: "%2e%2e%2fetc"},
+		{folder: "..", wantErr: true},
+		{folder: "../etc", wantErr: true},
+		{folder: "a/../../b", wantErr: true},
+		{folder: `a\..\b`, wantErr: true},
+		{folder: "/..", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := CleanFolder(tt.folder)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("CleanFolder(%q) = %q, want an error", tt.folder, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("CleanFolder(%q) = %q, %v, want %q", tt.folder, got, err, tt.want)
+		}
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "report.pdf", want: "report.pdf"},
+		{name: "my file.txt", want: "my file.txt"},
+		{name: "../../etc/passwd", want: "passwd"},
+		{name: "/etc/passwd", want: "passwd"},
+		{name: `C:\Windows\win.ini`, want: "win.ini"},
+		{name: ".hidden", want: "hidden"},
+		{name: "a<b>:c|d.txt", want: "a_b__c_d.txt"},
+		{name: "a\x00b\nc.txt", want: "abc.txt"},
+		{name: "..%2F..%2Fpasswd", want: "_2F.._2Fpasswd"},
+		{name: "%2e%2e", want: "_2e_2e"},
+		{name: "", wantErr: true},
+		{name: " ", wantErr: true},
+		{name: ".", wantErr: true},
+		{name: "..", wantErr: true},
+		{name: "/", wantErr: true},
+		{name: "docs/", wantErr: true},
+		{name: "docs/..", wantErr: true},
+		{name: "\x00\x01", wantErr: true},
+		{name: strings.Repeat("a", maxFileNameLen+1), wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SanitizeFileName(tt.name)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("SanitizeFileName(%q) = %q, want an error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("SanitizeFileName(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestSanitizeBlobName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "a.txt", want: "a.txt"},
+		{name: "docs/a.txt", want: "docs/a.txt"},
+		{name: "/abs/a.txt", want: "abs/a.txt"},
+		{name: "a/./b.txt", want: "a/b.txt"},
+		{name: `docs\a.txt`, want: "docs/a.txt"},
+		{name: "docs/..%2Fa.txt", want: "docs/_2Fa.txt"},
+		{name: "", wantErr: true},
+		{name: "docs/", wantErr: true},
+		{name: "../a.txt", wantErr: true},
+		{name: "docs/../../a.txt", wantErr: true},
+		{name: `docs\..\..\a.txt`, wantErr: true},
+		{name: "docs/..", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SanitizeBlobName(tt.name)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("SanitizeBlobName(%q) = %q, want an error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("SanitizeBlobName(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestCheckBlobName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "a.txt"},
+		{name: "docs/a.txt"},
+		// Names stored before sanitization are accepted as they are
+		{name: "odd name?.txt"},
+		{name: "..%2Fa.txt"},
+		{name: "..."},
+		{name: "", wantErr: true},
+		{name: "..", wantErr: true},
+		{name: ".", wantErr: true},
+		{name: "../a.txt", wantErr: true},
+		{name: "docs/../../a.txt", wantErr: true},
+		{name: "./a.txt", wantErr: true},
+		{name: "docs/.", wantErr: true},
+		{name: `docs\..\a.txt`, wantErr: true},
+		{name: "/etc/passwd", wantErr: true},
+		{name: "a\nb.txt", wantErr: true},
+		{name: "a\x00b.txt", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := checkBlobName(tt.name)
+		if gotErr := err != nil; gotErr != tt.wantErr {
+			t.Errorf("checkBlobName(%q) = %v, want error %t", tt.name, err, tt.wantErr)
+		}
+	}
+}
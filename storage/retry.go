@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+)
+
+// RetryOptions controls how AzureBlobStorage retries calls that fail
+// transiently, such as when Azure throttles requests or is briefly
+// unavailable.
+type RetryOptions struct {
+	// Retries is how many times a failed call is retried; zero disables
+	// retrying
+	Retries int
+	// Delay is the wait before the first retry, doubling for each later one
+	// up to maxRetryDelay. A longer Retry-After from Azure takes precedence.
+	Delay time.Duration
+}
+
+// DefaultRetryOptions are used by NewAzureBlobStorage unless overridden
+var DefaultRetryOptions = RetryOptions{Retries: 3, Delay: time.Second}
+
+// maxRetryDelay caps the wait between retries
+const maxRetryDelay = 30 * time.Second
+
+// permanentError marks an error that must not be retried even though it
+// looks transient, e.g. an upload whose data can't be read again
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+// do calls fn until it succeeds, fails with an error that isn't transient,
+// or has been retried o.Retries times, backing off between attempts. It
+// gives up early once ctx is done or its deadline would pass while waiting.
+// fn gets the attempt number, starting at 0.
+func (o RetryOptions) do(ctx context.Context, op, name string, fn func(attempt int) error) error {
+	delay := o.Delay
+	if delay <= 0 {
+		delay = DefaultRetryOptions.Delay
+	}
+	for attempt := 0; ; attempt++ {
+		err := fn(attempt)
+		if err == nil || attempt >= o.Retries || !isTransient(err) {
+			return err
+		}
+		wait := max(delay, retryAfter(err))
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+		slog.WarnContext(ctx, "Retrying blob storage call", "op", op, "name", name, "attempt", attempt+1, "delay", wait, "error", err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// isTransient reports whether err is a failure worth retrying: a throttled,
+// timed out or server-side error response, or a network error
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var perm permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryAfter returns how long an error response asked to wait before
+// retrying, or zero if it didn't say
+func retryAfter(err error) time.Duration {
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) || respErr.RawResponse == nil {
+		return 0
+	}
+	secs, convErr := strconv.Atoi(respErr.RawResponse.Header.Get("Retry-After"))
+	if convErr != nil || secs <= 0 {
+		return 0
+	}
+	return min(time.Duration(secs)*time.Second, maxRetryDelay)
+}
+
+// countingReader counts the bytes read through it, so an upload knows
+// whether a failed attempt consumed any of its data
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
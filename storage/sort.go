@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// File listing sort keys accepted by SortFiles
+const (
+	SortByName     = "name"
+	SortBySize     = "size"
+	SortByModified = "modified"
+)
+
+// SortFiles sorts files in place by name, size or modified time, ascending
+// unless order is "desc". Equal files keep their listing order, and files
+// without a modified time go last whichever the order.
+func SortFiles(files []FileInfo, by, order string) error {
+	var desc bool
+	switch order {
+	case "", "asc":
+	case "desc":
+		desc = true
+	default:
+		return fmt.Errorf("order must be asc or desc, got %q", order)
+	}
+
+	var compare func(a, b FileInfo) int
+	switch by {
+	case SortByName:
+		compare = func(a, b FileInfo) int { return strings.Compare(a.Name, b.Name) }
+	case SortBySize:
+		compare = func(a, b FileInfo) int { return cmp.Compare(a.Size, b.Size) }
+	case SortByModified:
+		compare = func(a, b FileInfo) int { return a.LastModified.Compare(*b.LastModified) }
+	default:
+		return fmt.Errorf("sort must be %s, %s or %s, got %q", SortByName, SortBySize, SortByModified, by)
+	}
+
+	slices.SortStableFunc(files, func(a, b FileInfo) int {
+		if by == SortByModified && (a.LastModified == nil || b.LastModified == nil) {
+			return boolToInt(a.LastModified == nil) - boolToInt(b.LastModified == nil)
+		}
+		if desc {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	})
+	return nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
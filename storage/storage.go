@@ -2,19 +2,78 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
+	"time"
 )
 
+// ErrNotFound is returned when the named file does not exist
+var ErrNotFound = errors.New("file not found")
+
+// ErrAlreadyExists is returned by CreateFile when the named file exists
+var ErrAlreadyExists = errors.New("file already exists")
+
+// ErrPreconditionFailed is returned by conditional operations when the file's
+// current ETag does not match the one the caller expected.
+var ErrPreconditionFailed = errors.New("file was modified or does not match the expected version")
+
 type FileInfo struct {
 	Name string `json:"name"`
 	URL  string `json:"url"`
+	// ETag identifies the stored version, for use with the IfMatch methods
+	ETag string `json:"etag,omitempty"`
+	// ContentMD5 is the hex MD5 of the content, reported by uploads and, if
+	// the backend recorded one, by listings
+	ContentMD5 string `json:"contentMD5,omitempty"`
+	// Size and LastModified are filled in by listings
+	Size         int64      `json:"size,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+}
+
+// FilePage is one page of a file listing. Continuation is empty on the last
+// page; otherwise pass it back to ListFilesPage to get the next one.
+type FilePage struct {
+	Files        []FileInfo `json:"files"`
+	Continuation string     `json:"continuation,omitempty"`
 }
 
 // Storage defines a generic file storage interface.
 type Storage interface {
-	ListFiles(ctx context.Context) ([]FileInfo, error)
+	// ListFiles returns every file whose name starts with prefix ("" for
+	// all files).
+	ListFiles(ctx context.Context, prefix string) ([]FileInfo, error)
+	// ListFilesPage returns up to limit files under prefix, starting at the
+	// continuation token from a previous page ("" for the first page).
+	ListFilesPage(ctx context.Context, prefix, continuation string, limit int) (FilePage, error)
 	DownloadFile(ctx context.Context, name string) (io.ReadCloser, error)
 	UploadFile(ctx context.Context, name string, data io.Reader) (FileInfo, error)
 	DeleteFile(ctx context.Context, name string) error
+	// UploadFileIfMatch and DeleteFileIfMatch only act if the file's current
+	// ETag equals ifMatch, returning ErrPreconditionFailed otherwise.
+	UploadFileIfMatch(ctx context.Context, name string, data io.Reader, ifMatch string) (FileInfo, error)
+	DeleteFileIfMatch(ctx context.Context, name, ifMatch string) error
+	// CreateFile uploads a file only if none exists with the name, returning
+	// ErrAlreadyExists otherwise. Of several concurrent creates exactly one
+	// succeeds.
+	CreateFile(ctx context.Context, name string, data io.Reader) (FileInfo, error)
 	RenameFile(ctx context.Context, oldName, newName string) error
+	// SignedURL returns a read-only URL for the named file that stops
+	// working after ttl.
+	SignedURL(ctx context.Context, name string, ttl time.Duration) (string, error)
+}
+
+// CopyFile streams the file srcName in src into dst as dstName, overwriting
+// any file there, or only one whose ETag matches ifMatch if it is set. The
+// two stores may be different backends, since the data passes through this
+// process rather than being copied server-side.
+func CopyFile(ctx context.Context, src, dst Storage, srcName, dstName, ifMatch string) (FileInfo, error) {
+	data, err := src.DownloadFile(ctx, srcName)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	defer data.Close()
+	if ifMatch != "" {
+		return dst.UploadFileIfMatch(ctx, dstName, data, ifMatch)
+	}
+	return dst.UploadFile(ctx, dstName, data)
 }
@@ -0,0 +1,222 @@
+// Package storagetest provides a conformance suite for storage.Storage
+// implementations.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"tapasrm.dev/cron-ui/storage"
+)
+
+// Run checks that the Storage returned by newStore implements the list,
+// download, upload, delete and rename semantics the rest of the app relies
+// on. newStore is called once per subtest and must return an empty store.
+func Run(t *testing.T, newStore func(t *testing.T) storage.Storage) {
+	t.Run("UploadDownload", func(t *testing.T) {
+		s := newStore(t)
+		info := upload(t, s, "a.txt", "hello")
+		if info.Name != "a.txt" {
+			t.Errorf("UploadFile name = %q, want %q", info.Name, "a.txt")
+		}
+		if want := "5d41402abc4b2a76b9719d911017c592"; info.ContentMD5 != want {
+			t.Errorf("UploadFile ContentMD5 = %q, want %q", info.ContentMD5, want)
+		}
+		if got := download(t, s, "a.txt"); got != "hello" {
+			t.Errorf("DownloadFile = %q, want %q", got, "hello")
+		}
+
+		upload(t, s, "a.txt", "replaced")
+		if got := download(t, s, "a.txt"); got != "replaced" {
+			t.Errorf("DownloadFile after overwrite = %q, want %q", got, "replaced")
+		}
+	})
+
+	t.Run("DownloadMissing", func(t *testing.T) {
+		s := newStore(t)
+		_, err := s.DownloadFile(context.Background(), "missing.txt")
+		wantNotFound(t, "DownloadFile", err)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		s := newStore(t)
+		for _, name := range []string{"images/a.png", "images/b.png", "docs/c.md"} {
+			upload(t, s, name, name)
+		}
+
+		all, err := s.ListFiles(context.Background(), "")
+		if err != nil {
+			t.Fatalf("ListFiles: %v", err)
+		}
+		if got := names(all); got != "docs/c.md,images/a.png,images/b.png" {
+			t.Errorf("ListFiles = %s", got)
+		}
+
+		images, err := s.ListFiles(context.Background(), "images/")
+		if err != nil {
+			t.Fatalf("ListFiles with prefix: %v", err)
+		}
+		if got := names(images); got != "images/a.png,images/b.png" {
+			t.Errorf("ListFiles(images/) = %s", got)
+		}
+	})
+
+	t.Run("ListPages", func(t *testing.T) {
+		s := newStore(t)
+		for _, name := range []string{"a", "b", "c", "d", "e"} {
+			upload(t, s, name, name)
+		}
+
+		var seen []storage.FileInfo
+		token := ""
+		for i := 0; ; i++ {
+			if i > 5 {
+				t.Fatal("ListFilesPage did not finish")
+			}
+			page, err := s.ListFilesPage(context.Background(), "", token, 2)
+			if err != nil {
+				t.Fatalf("ListFilesPage: %v", err)
+			}
+			if len(page.Files) > 2 {
+				t.Fatalf("ListFilesPage returned %d files, limit was 2", len(page.Files))
+			}
+			seen = append(seen, page.Files...)
+			if page.Continuation == "" {
+				break
+			}
+			token = page.Continuation
+		}
+		if got := names(seen); got != "a,b,c,d,e" {
+			t.Errorf("paged listing = %s", got)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		s := newStore(t)
+		upload(t, s, "a.txt", "hello")
+		if err := s.DeleteFile(context.Background(), "a.txt"); err != nil {
+			t.Fatalf("DeleteFile: %v", err)
+		}
+		_, err := s.DownloadFile(context.Background(), "a.txt")
+		wantNotFound(t, "DownloadFile after delete", err)
+		wantNotFound(t, "DeleteFile of missing file", s.DeleteFile(context.Background(), "a.txt"))
+	})
+
+	t.Run("Conditional", func(t *testing.T) {
+		s := newStore(t)
+		info := upload(t, s, "a.txt", "v1")
+		if info.ETag == "" {
+			t.Fatal("UploadFile returned no ETag")
+		}
+
+		next, err := s.UploadFileIfMatch(context.Background(), "a.txt", strings.NewReader("v2"), info.ETag)
+		if err != nil {
+			t.Fatalf("UploadFileIfMatch with current ETag: %v", err)
+		}
+		if next.ETag == info.ETag {
+			t.Error("ETag did not change after overwrite")
+		}
+
+		_, err = s.UploadFileIfMatch(context.Background(), "a.txt", strings.NewReader("v3"), info.ETag)
+		wantPreconditionFailed(t, "UploadFileIfMatch with stale ETag", err)
+		wantPreconditionFailed(t, "DeleteFileIfMatch with stale ETag", s.DeleteFileIfMatch(context.Background(), "a.txt", info.ETag))
+		if got := download(t, s, "a.txt"); got != "v2" {
+			t.Errorf("file after failed conditional writes = %q, want %q", got, "v2")
+		}
+
+		if err := s.DeleteFileIfMatch(context.Background(), "a.txt", next.ETag); err != nil {
+			t.Fatalf("DeleteFileIfMatch with current ETag: %v", err)
+		}
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		s := newStore(t)
+		if _, err := s.CreateFile(context.Background(), "a.txt", strings.NewReader("v1")); err != nil {
+			t.Fatalf("CreateFile of new file: %v", err)
+		}
+		_, err := s.CreateFile(context.Background(), "a.txt", strings.NewReader("v2"))
+		if !errors.Is(err, storage.ErrAlreadyExists) {
+			t.Fatalf("CreateFile of existing file: got %v, want ErrAlreadyExists", err)
+		}
+		if got := download(t, s, "a.txt"); got != "v1" {
+			t.Errorf("file after failed create = %q, want %q", got, "v1")
+		}
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		s := newStore(t)
+		upload(t, s, "old.txt", "hello")
+		if err := s.RenameFile(context.Background(), "old.txt", "new.txt"); err != nil {
+			t.Fatalf("RenameFile: %v", err)
+		}
+		if got := download(t, s, "new.txt"); got != "hello" {
+			t.Errorf("renamed file = %q, want %q", got, "hello")
+		}
+		_, err := s.DownloadFile(context.Background(), "old.txt")
+		wantNotFound(t, "DownloadFile of old name", err)
+		wantNotFound(t, "RenameFile of missing file", s.RenameFile(context.Background(), "old.txt", "other.txt"))
+	})
+
+	t.Run("SignedURL", func(t *testing.T) {
+		s := newStore(t)
+		upload(t, s, "a.txt", "hello")
+		url, err := s.SignedURL(context.Background(), "a.txt", time.Minute)
+		if err != nil {
+			t.Fatalf("SignedURL: %v", err)
+		}
+		if url == "" {
+			t.Error("SignedURL returned an empty URL")
+		}
+		_, err = s.SignedURL(context.Background(), "missing.txt", time.Minute)
+		wantNotFound(t, "SignedURL of missing file", err)
+	})
+}
+
+func upload(t *testing.T, s storage.Storage, name, content string) storage.FileInfo {
+	t.Helper()
+	info, err := s.UploadFile(context.Background(), name, strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("UploadFile(%q): %v", name, err)
+	}
+	return info
+}
+
+func download(t *testing.T, s storage.Storage, name string) string {
+	t.Helper()
+	rc, err := s.DownloadFile(context.Background(), name)
+	if err != nil {
+		t.Fatalf("DownloadFile(%q): %v", name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("reading %q: %v", name, err)
+	}
+	return string(data)
+}
+
+func wantNotFound(t *testing.T, op string, err error) {
+	t.Helper()
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("%s: got %v, want storage.ErrNotFound", op, err)
+	}
+}
+
+func wantPreconditionFailed(t *testing.T, op string, err error) {
+	t.Helper()
+	if !errors.Is(err, storage.ErrPreconditionFailed) {
+		t.Errorf("%s: got %v, want storage.ErrPreconditionFailed", op, err)
+	}
+}
+
+func names(files []storage.FileInfo) string {
+	out := make([]string, len(files))
+	for i, f := range files {
+		out[i] = f.Name
+	}
+	return strings.Join(out, ",")
+}